@@ -0,0 +1,108 @@
+package authz
+
+import (
+	"context"
+	"log"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type Action string
+
+const (
+	UpdateAction     Action = "update"
+	DeleteAction     Action = "delete"
+	UpdateRoleAction Action = "update_role"
+	UnlockAction     Action = "unlock"
+)
+
+const (
+	PostResource    = "post"
+	UserResource    = "user"
+	SessionResource = "session"
+	APIKeyResource  = "api_key"
+)
+
+// Resource is what an action is performed on: its type and the user that
+// owns it.
+type Resource struct {
+	Type    string
+	Id      any
+	OwnerId int64
+}
+
+func Post(post *models.Post) Resource {
+	return Resource{Type: PostResource, Id: post.ID, OwnerId: post.UserID}
+}
+
+// User is owned by itself.
+func User(userId int64) Resource {
+	return Resource{Type: UserResource, Id: userId, OwnerId: userId}
+}
+
+func Session(session *models.Session) Resource {
+	return Resource{Type: SessionResource, Id: session.ID, OwnerId: session.UserID}
+}
+
+func APIKey(key *models.APIKey) Resource {
+	return Resource{Type: APIKeyResource, Id: key.ID, OwnerId: key.UserID}
+}
+
+// Rule allows its actions on a resource type to its owner, when Owner is
+// set, and to any user with one of its roles.
+type Rule struct {
+	Resource string
+	Actions  []Action
+	Owner    bool
+	Roles    []string
+}
+
+var Rules = []Rule{
+	{Resource: PostResource, Actions: []Action{UpdateAction, DeleteAction}, Owner: true},
+	{Resource: PostResource, Actions: []Action{UpdateAction, DeleteAction}, Roles: []string{models.AdminRole}},
+	// Moderators can remove abusive posts they don't own.
+	{Resource: PostResource, Actions: []Action{DeleteAction}, Roles: []string{models.ModeratorRole}},
+
+	{Resource: UserResource, Actions: []Action{UpdateAction, DeleteAction}, Owner: true},
+	{Resource: UserResource, Actions: []Action{UpdateAction, DeleteAction, UpdateRoleAction, UnlockAction}, Roles: []string{models.AdminRole}},
+
+	{Resource: SessionResource, Actions: []Action{DeleteAction}, Owner: true},
+
+	{Resource: APIKeyResource, Actions: []Action{DeleteAction}, Owner: true},
+}
+
+func (rule Rule) allows(claims *models.AppClaims, action Action, resource Resource) bool {
+	if rule.Resource != resource.Type || !contains(rule.Actions, action) {
+		return false
+	}
+
+	if rule.Owner && resource.OwnerId == claims.UserId {
+		return true
+	}
+
+	return len(rule.Roles) > 0 && claims.HasRole(rule.Roles...)
+}
+
+// Can reports whether the claims allow the action on the resource. Anything
+// not explicitly allowed by a rule is denied, and denials are logged.
+func Can(ctx context.Context, claims *models.AppClaims, action Action, resource Resource) bool {
+	for _, rule := range Rules {
+		if rule.allows(claims, action, resource) {
+			return true
+		}
+	}
+
+	log.Printf("authz: denied %s on %s %v to user %d (role %q)", action, resource.Type, resource.Id, claims.UserId, claims.Role)
+
+	return false
+}
+
+func contains(actions []Action, action Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
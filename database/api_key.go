@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"tincho.dev/rest-ws/models"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	var k models.APIKey
+
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &k, nil
+}
+
+func (p *Postgres) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	var expiresAt *time.Time
+
+	if key.ExpiresAt != nil {
+		expiration := key.ExpiresAt.UTC()
+		expiresAt = &expiration
+	}
+
+	row := p.db.QueryRowContext(ctx, "INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at", key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), expiresAt)
+
+	return translateError(row.Scan(&key.ID, &key.CreatedAt))
+}
+
+func (p *Postgres) FindAPIKeyById(ctx context.Context, id int64) (*models.APIKey, error) {
+	return scanAPIKey(p.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id))
+}
+
+func (p *Postgres) FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return scanAPIKey(p.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+}
+
+func (p *Postgres) ListAPIKeys(ctx context.Context, userId int64) ([]models.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, *k)
+	}
+
+	return keys, nil
+}
+
+// TouchAPIKey records usage at most once a minute, so authenticated requests
+// don't all turn into writes.
+func (p *Postgres) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')", id)
+
+	return err
+}
+
+func (p *Postgres) DeleteAPIKey(ctx context.Context, id int64) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", id)
+
+	return err
+}
+
+func (p *Postgres) DeleteUserAPIKeys(ctx context.Context, userId int64) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM api_keys WHERE user_id = $1", userId)
+
+	return err
+}
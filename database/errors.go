@@ -0,0 +1,51 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"tincho.dev/rest-ws/repositories"
+)
+
+// The Postgres error codes of a unique and a foreign key constraint.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// translateError turns the errors of the driver into the errors of the
+// repositories.
+func translateError(err error) error {
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return repositories.ErrNotFound
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return repositories.ErrConflict
+	case errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation:
+		return repositories.ErrNotFound
+	}
+
+	return err
+}
+
+// checkAffected returns ErrNotFound when the statement didn't change any row.
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return repositories.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (p *Postgres) CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error {
+	row := p.db.QueryRowContext(ctx, "INSERT INTO external_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at", identity.UserID, identity.Issuer, identity.Subject, identity.Email)
+
+	return translateError(row.Scan(&identity.ID, &identity.CreatedAt))
+}
+
+func (p *Postgres) FindExternalIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, user_id, issuer, subject, email, created_at FROM external_identities WHERE issuer = $1 AND subject = $2", issuer, subject)
+
+	var i models.ExternalIdentity
+
+	err := row.Scan(&i.ID, &i.UserID, &i.Issuer, &i.Subject, &i.Email, &i.CreatedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &i, nil
+}
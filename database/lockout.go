@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func (p *Postgres) RecordLoginAttempt(ctx context.Context, key string, at time.Time, windowStart time.Time) (int, time.Time, error) {
+	// Failures out of the window don't count anymore, there is no need to
+	// remember them.
+	_, err := p.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE last_failure_at < $1", windowStart.UTC())
+
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	// The row is locked first, so that concurrent attempts read the last
+	// failure after one another.
+	query := `
+		WITH previous AS (
+			SELECT last_failure_at FROM login_attempts WHERE key = $1 FOR UPDATE
+		)
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET failures = login_attempts.failures + 1, last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures - 1, (SELECT last_failure_at FROM previous)
+	`
+
+	var failures int
+	var last sql.NullTime
+
+	err = p.db.QueryRowContext(ctx, query, key, postgresTime(at)).Scan(&failures, &last)
+
+	return failures, last.Time, err
+}
+
+func (p *Postgres) ForgetLoginAttempt(ctx context.Context, key string, at time.Time, previous time.Time) error {
+	// A later attempt is the last one already.
+	query := `
+		UPDATE login_attempts
+		SET failures = failures - 1, last_failure_at = CASE WHEN last_failure_at = $2 THEN $3 ELSE last_failure_at END
+		WHERE key = $1
+	`
+
+	_, err := p.db.ExecContext(ctx, query, key, postgresTime(at), postgresTime(previous))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1 AND failures <= 0", key)
+
+	return err
+}
+
+// postgresTime rounds the time to the microseconds Postgres keeps, so it can
+// be compared with the stored one.
+func postgresTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func (p *Postgres) ResetLoginFailures(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+
+	return err
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (p *Postgres) SaveMFASecret(ctx context.Context, userId int64, secret string) error {
+	query := `
+		INSERT INTO mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = $2, confirmed_at = NULL, last_step = 0
+	`
+
+	_, err := p.db.ExecContext(ctx, query, userId, secret)
+
+	return err
+}
+
+func (p *Postgres) FindMFA(ctx context.Context, userId int64) (*models.MFA, error) {
+	row := p.db.QueryRowContext(ctx, "SELECT user_id, secret, confirmed_at, last_step FROM mfa WHERE user_id = $1", userId)
+
+	var mfa models.MFA
+
+	err := row.Scan(&mfa.UserID, &mfa.Secret, &mfa.ConfirmedAt, &mfa.LastStep)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &mfa, nil
+}
+
+func (p *Postgres) ConfirmMFA(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE mfa SET confirmed_at = NOW() WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userId, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p *Postgres) DeleteMFA(ctx context.Context, userId int64) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, "DELETE FROM mfa WHERE user_id = $1", userId)
+
+	return err
+}
+
+func (p *Postgres) UseMFAStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	result, err := p.db.ExecContext(ctx, "UPDATE mfa SET last_step = $2 WHERE user_id = $1 AND last_step < $2", userId, step)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (p *Postgres) UseRecoveryCode(ctx context.Context, userId int64, hash string) (bool, error) {
+	result, err := p.db.ExecContext(ctx, "UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL", userId, hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
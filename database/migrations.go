@@ -0,0 +1,24 @@
+package database
+
+import (
+	"embed"
+	"io/fs"
+
+	"tincho.dev/rest-ws/migrate"
+)
+
+// PostgresMigrationsDir is where `migrate create` adds new migrations.
+const PostgresMigrationsDir = "database/migrations/postgres"
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+func PostgresMigrations() fs.FS {
+	migrations, _ := fs.Sub(postgresMigrations, "migrations/postgres")
+
+	return migrations
+}
+
+func (p *Postgres) Migrator() (*migrate.Migrator, error) {
+	return migrate.New(p.db, PostgresMigrations(), migrate.PostgresLock{})
+}
@@ -1,15 +1,4 @@
-DROP TABLE IF EXISTS "users";
-
-CREATE TABLE "users" (
-  "id" SERIAL PRIMARY KEY,
-  "email" VARCHAR(255) NOT NULL UNIQUE,
-  "password" VARCHAR(255) NOT NULL,
-  "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
-);
-
-DROP TABLE IF EXISTS "posts";
-
-CREATE TABLE "posts" (
+CREATE TABLE IF NOT EXISTS "posts" (
   "id" SERIAL PRIMARY KEY,
   "title" VARCHAR(255) NOT NULL,
   "content" TEXT NOT NULL,
@@ -17,4 +6,4 @@ CREATE TABLE "posts" (
   "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
   "updated_at" TIMESTAMP NOT NULL DEFAULT NOW(),
   FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
-);
\ No newline at end of file
+);
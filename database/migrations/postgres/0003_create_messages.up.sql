@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "messages" (
+  "id" SERIAL PRIMARY KEY,
+  "sender_id" INT NOT NULL,
+  "recipient_id" INT NOT NULL,
+  "content" TEXT NOT NULL,
+  "read_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY ("sender_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("recipient_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "messages_conversation_idx" ON "messages" ("sender_id", "recipient_id", "created_at");
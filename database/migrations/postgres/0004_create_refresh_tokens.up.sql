@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "family_id" VARCHAR(64) NOT NULL,
+  "token_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "expires_at" TIMESTAMP NOT NULL,
+  "rotated_at" TIMESTAMP,
+  "revoked_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "refresh_tokens_family_idx" ON "refresh_tokens" ("family_id");
@@ -0,0 +1,11 @@
+-- "jti" holds either a token id or a session id.
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+  "jti" VARCHAR(64) PRIMARY KEY,
+  "expires_at" TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "user_revocations" (
+  "user_id" INT PRIMARY KEY,
+  "issued_before" TIMESTAMP NOT NULL,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
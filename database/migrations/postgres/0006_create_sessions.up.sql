@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "sessions" (
+  "id" VARCHAR(64) PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "user_agent" TEXT NOT NULL,
+  "ip" VARCHAR(64) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  "last_seen_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  "expires_at" TIMESTAMP NOT NULL,
+  "revoked_at" TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "sessions_user_idx" ON "sessions" ("user_id");
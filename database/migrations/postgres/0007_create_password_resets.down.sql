@@ -0,0 +1 @@
+DROP TABLE "password_resets";
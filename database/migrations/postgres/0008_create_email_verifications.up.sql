@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "email_verifications" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "token_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "expires_at" TIMESTAMP NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
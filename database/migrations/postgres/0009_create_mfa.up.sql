@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "mfa" (
+  "user_id" INT PRIMARY KEY,
+  "secret" VARCHAR(64) NOT NULL,
+  "confirmed_at" TIMESTAMP,
+  "last_step" BIGINT NOT NULL DEFAULT 0,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "mfa_recovery_codes" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "code_hash" VARCHAR(64) NOT NULL,
+  "used_at" TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "mfa_recovery_codes_user_idx" ON "mfa_recovery_codes" ("user_id");
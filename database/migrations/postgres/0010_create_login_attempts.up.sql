@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+  "key" VARCHAR(300) PRIMARY KEY,
+  "failures" INT NOT NULL,
+  "last_failure_at" TIMESTAMP NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "prefix" VARCHAR(20) NOT NULL,
+  "key_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "scopes" TEXT[] NOT NULL DEFAULT '{}',
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  "expires_at" TIMESTAMP,
+  "last_used_at" TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "api_keys_user_idx" ON "api_keys" ("user_id");
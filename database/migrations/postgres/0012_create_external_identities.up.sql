@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "external_identities" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "issuer" VARCHAR(255) NOT NULL,
+  "subject" VARCHAR(255) NOT NULL,
+  "email" VARCHAR(255) NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE ("issuer", "subject"),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- The links sent before this migration aren't bound to an email, so they
+-- stop working and the users have to ask for a new one.
+ALTER TABLE "email_verifications" ADD COLUMN "email" VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS "notifications" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "payload" TEXT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (p *Postgres) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	row := p.db.QueryRowContext(ctx, "INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id", reset.UserID, reset.TokenHash, reset.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&reset.ID))
+}
+
+func (p *Postgres) FindPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordReset, error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, user_id, token_hash, expires_at, used_at FROM password_resets WHERE token_hash = $1", hash)
+
+	var reset models.PasswordReset
+
+	err := row.Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &reset.UsedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &reset, nil
+}
+
+func (p *Postgres) UsePasswordReset(ctx context.Context, id int64) (bool, error) {
+	result, err := p.db.ExecContext(ctx, "UPDATE password_resets SET used_at = NOW() WHERE id = $1 AND used_at IS NULL", id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
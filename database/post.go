@@ -7,9 +7,9 @@ import (
 )
 
 func (p *Postgres) CreatePost(ctx context.Context, post *models.Post) error {
-	_, err := p.db.ExecContext(ctx, "INSERT INTO posts (title, content, user_id) VALUES ($1, $2, $3)", post.Title, post.Content, post.UserID)
+	row := p.db.QueryRowContext(ctx, "INSERT INTO posts (title, content, user_id) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at", post.Title, post.Content, post.UserID)
 
-	return err
+	return translateError(row.Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt))
 }
 
 func (p *Postgres) FindAllPosts(ctx context.Context) ([]models.Post, error) {
@@ -46,7 +46,7 @@ func (p *Postgres) FindPostById(ctx context.Context, id int64) (*models.Post, er
 	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &post, nil
@@ -79,15 +79,11 @@ func (p *Postgres) FindPostsByUserId(ctx context.Context, userId int64) ([]model
 }
 
 func (p *Postgres) UpdatePost(ctx context.Context, post *models.Post) error {
-	_, err := p.db.ExecContext(ctx, "UPDATE posts SET title = $1, content = $2, updated_at = NOW() WHERE id = $3", post.Title, post.Content, post.ID)
-
-	return err
+	return checkAffected(p.db.ExecContext(ctx, "UPDATE posts SET title = $1, content = $2, updated_at = NOW() WHERE id = $3", post.Title, post.Content, post.ID))
 }
 
 func (p *Postgres) DeletePost(ctx context.Context, id int64) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", id)
-
-	return err
+	return checkAffected(p.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", id))
 }
 
 func (p *Postgres) Close() error {
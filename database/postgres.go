@@ -7,7 +7,8 @@ import (
 )
 
 type Postgres struct {
-	db *sql.DB
+	db  *sql.DB
+	url string
 }
 
 func NewPostgres(url string) (*Postgres, error) {
@@ -17,5 +18,5 @@ func NewPostgres(url string) (*Postgres, error) {
 		return nil, err
 	}
 
-	return &Postgres{db}, nil
+	return &Postgres{db: db, url: url}, nil
 }
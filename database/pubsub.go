@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"tincho.dev/rest-ws/models"
+)
+
+const (
+	notificationChannel = "rest_ws_events"
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more.
+	maxNotificationSize = 7999
+	listenerPingPeriod  = 90 * time.Second
+	// Stored notifications are kept long enough for every listener to load
+	// them.
+	storedNotificationRetention = time.Minute
+)
+
+// notificationReference is sent over NOTIFY in place of a notification too
+// large for it, which is stored in the notifications table instead.
+type notificationReference struct {
+	StoredId int64 `json:"stored_id"`
+}
+
+func (p *Postgres) Publish(ctx context.Context, n *models.Notification) error {
+	data, err := json.Marshal(n)
+
+	if err != nil {
+		return err
+	}
+
+	if len(data) > maxNotificationSize {
+		data, err = p.storeNotification(ctx, data)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notificationChannel, string(data))
+
+	return err
+}
+
+// storeNotification saves a notification and returns the reference to
+// publish in its place.
+func (p *Postgres) storeNotification(ctx context.Context, data []byte) ([]byte, error) {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM notifications WHERE created_at < $1", time.Now().UTC().Add(-storedNotificationRetention))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var reference notificationReference
+
+	err = p.db.QueryRowContext(ctx, "INSERT INTO notifications (payload) VALUES ($1) RETURNING id", string(data)).Scan(&reference.StoredId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(reference)
+}
+
+// loadNotification decodes a NOTIFY payload, reading the notification from
+// the notifications table when it was too large to be sent.
+func (p *Postgres) loadNotification(ctx context.Context, payload string) (*models.Notification, error) {
+	var reference notificationReference
+
+	if err := json.Unmarshal([]byte(payload), &reference); err != nil {
+		return nil, err
+	}
+
+	if reference.StoredId != 0 {
+		err := p.db.QueryRowContext(ctx, "SELECT payload FROM notifications WHERE id = $1", reference.StoredId).Scan(&payload)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var notification models.Notification
+
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Subscribe listens on a dedicated connection, so every instance sharing the
+// database receives every notification, including its own.
+func (p *Postgres) Subscribe(ctx context.Context) (<-chan *models.Notification, error) {
+	listener := pq.NewListener(p.url, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Postgres listener error: ", err)
+		}
+	})
+
+	if err := listener.Listen(notificationChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	notifications := make(chan *models.Notification, 256)
+
+	go func() {
+		ticker := time.NewTicker(listenerPingPeriod)
+
+		defer func() {
+			ticker.Stop()
+			listener.Close()
+			close(notifications)
+		}()
+
+		for {
+			select {
+			case n := <-listener.Notify:
+				// A nil notification means the connection was re-established;
+				// anything sent in between is lost.
+				if n == nil {
+					continue
+				}
+
+				notification, err := p.loadNotification(ctx, n.Extra)
+
+				if err != nil {
+					log.Println("Error decoding notification: ", err)
+					continue
+				}
+
+				select {
+				case notifications <- notification:
+				case <-ctx.Done():
+					return
+				}
+			case <-ticker.C:
+				go listener.Ping()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return notifications, nil
+}
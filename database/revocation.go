@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+func (p *Postgres) RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	// Expired tokens are rejected anyway, there is no need to remember them.
+	_, err := p.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < $1", time.Now().UTC())
+
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING", id, expiresAt.UTC())
+
+	return err
+}
+
+func (p *Postgres) RevokeUserTokens(ctx context.Context, userId int64, issuedBefore time.Time) error {
+	query := `
+		INSERT INTO user_revocations (user_id, issued_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET issued_before = EXCLUDED.issued_before
+	`
+
+	_, err := p.db.ExecContext(ctx, query, userId, postgresTime(issuedBefore))
+
+	return err
+}
+
+func (p *Postgres) IsTokenRevoked(ctx context.Context, jti string, sessionId string, userId int64, issuedAt time.Time) (bool, error) {
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM revoked_tokens WHERE jti IN ($1, $2) AND jti <> '') OR
+			EXISTS (SELECT 1 FROM user_revocations WHERE user_id = $3 AND issued_before >= $4)
+	`
+
+	var revoked bool
+
+	err := p.db.QueryRowContext(ctx, query, jti, sessionId, userId, issuedAt.UTC()).Scan(&revoked)
+
+	return revoked, err
+}
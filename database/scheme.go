@@ -0,0 +1,24 @@
+package database
+
+import "strings"
+
+// The scheme of the database url selects the backend.
+const (
+	PostgresScheme = "postgres"
+	SQLiteScheme   = "sqlite"
+)
+
+// Scheme returns the backend of a database url, or an empty string when it
+// isn't supported.
+func Scheme(databaseURL string) string {
+	scheme, _, _ := strings.Cut(databaseURL, ":")
+
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return PostgresScheme
+	case "sqlite":
+		return SQLiteScheme
+	}
+
+	return ""
+}
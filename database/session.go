@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (p *Postgres) CreateSession(ctx context.Context, session *models.Session) error {
+	row := p.db.QueryRowContext(ctx, "INSERT INTO sessions (id, user_id, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, last_seen_at", session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&session.CreatedAt, &session.LastSeenAt))
+}
+
+func (p *Postgres) FindSessionById(ctx context.Context, id string) (*models.Session, error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at FROM sessions WHERE id = $1", id)
+
+	var s models.Session
+
+	err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &s, nil
+}
+
+func (p *Postgres) ListActiveSessions(ctx context.Context, userId int64) ([]models.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, userId, time.Now().UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []models.Session{}
+
+	for rows.Next() {
+		var s models.Session
+
+		err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity at most once a minute, so authenticated
+// requests don't all turn into writes.
+func (p *Postgres) TouchSession(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE sessions SET last_seen_at = NOW() WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute'", id)
+
+	return err
+}
+
+func (p *Postgres) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE sessions SET last_seen_at = NOW(), expires_at = $1 WHERE id = $2", expiresAt.UTC(), id)
+
+	return err
+}
+
+func (p *Postgres) RevokeSession(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+
+	return err
+}
+
+func (p *Postgres) RevokeUserSessions(ctx context.Context, userId int64) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userId)
+
+	return err
+}
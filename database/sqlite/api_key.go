@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAPIKey reads a key, whose scopes are stored as a JSON array.
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	var k models.APIKey
+	var scopes string
+
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	err = json.Unmarshal([]byte(scopes), &k.Scopes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+func (s *SQLite) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	var expiresAt *time.Time
+
+	if key.ExpiresAt != nil {
+		expiration := key.ExpiresAt.UTC()
+		expiresAt = &expiration
+	}
+
+	scopes, err := json.Marshal(append([]string{}, key.Scopes...))
+
+	if err != nil {
+		return err
+	}
+
+	row := s.db.QueryRowContext(ctx, "INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at", key.UserID, key.Name, key.Prefix, key.KeyHash, string(scopes), expiresAt, time.Now().UTC())
+
+	return translateError(row.Scan(&key.ID, &key.CreatedAt))
+}
+
+func (s *SQLite) FindAPIKeyById(ctx context.Context, id int64) (*models.APIKey, error) {
+	return scanAPIKey(s.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1", id))
+}
+
+func (s *SQLite) FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return scanAPIKey(s.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+}
+
+func (s *SQLite) ListAPIKeys(ctx context.Context, userId int64) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, *k)
+	}
+
+	return keys, nil
+}
+
+// TouchAPIKey records usage at most once a minute, so authenticated requests
+// don't all turn into writes.
+func (s *SQLite) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)", time.Now().UTC(), id, time.Now().Add(-time.Minute).UTC())
+
+	return err
+}
+
+func (s *SQLite) DeleteAPIKey(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", id)
+
+	return err
+}
+
+func (s *SQLite) DeleteUserAPIKeys(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE user_id = $1", userId)
+
+	return err
+}
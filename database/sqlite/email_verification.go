@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateEmailVerification(ctx context.Context, verification *models.EmailVerification) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO email_verifications (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id", verification.UserID, verification.Email, verification.TokenHash, verification.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&verification.ID))
+}
+
+func (s *SQLite) FindEmailVerificationByHash(ctx context.Context, hash string) (*models.EmailVerification, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, user_id, email, token_hash, expires_at, used_at FROM email_verifications WHERE token_hash = $1", hash)
+
+	var verification models.EmailVerification
+
+	err := row.Scan(&verification.ID, &verification.UserID, &verification.Email, &verification.TokenHash, &verification.ExpiresAt, &verification.UsedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &verification, nil
+}
+
+func (s *SQLite) UseEmailVerification(ctx context.Context, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE email_verifications SET used_at = $1 WHERE id = $2 AND used_at IS NULL", time.Now().UTC(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
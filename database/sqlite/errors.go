@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"tincho.dev/rest-ws/repositories"
+)
+
+// translateError turns the errors of the driver into the errors of the
+// repositories.
+func translateError(err error) error {
+	var sqliteErr *sqlite.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return repositories.ErrNotFound
+	case errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY):
+		return repositories.ErrConflict
+	case errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return repositories.ErrNotFound
+	}
+
+	return err
+}
+
+// checkAffected returns ErrNotFound when the statement didn't change any row.
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return repositories.ErrNotFound
+	}
+
+	return nil
+}
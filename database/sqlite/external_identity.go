@@ -0,0 +1,28 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO external_identities (user_id, issuer, subject, email, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at", identity.UserID, identity.Issuer, identity.Subject, identity.Email, time.Now().UTC())
+
+	return translateError(row.Scan(&identity.ID, &identity.CreatedAt))
+}
+
+func (s *SQLite) FindExternalIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, user_id, issuer, subject, email, created_at FROM external_identities WHERE issuer = $1 AND subject = $2", issuer, subject)
+
+	var i models.ExternalIdentity
+
+	err := row.Scan(&i.ID, &i.UserID, &i.Issuer, &i.Subject, &i.Email, &i.CreatedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &i, nil
+}
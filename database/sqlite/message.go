@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateMessage(ctx context.Context, message *models.Message) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO messages (sender_id, recipient_id, content, created_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at", message.SenderID, message.RecipientID, message.Content, time.Now().UTC())
+
+	return translateError(row.Scan(&message.ID, &message.CreatedAt))
+}
+
+func (s *SQLite) ListMessages(ctx context.Context, userId int64, otherUserId int64, offset int64, limit int64) ([]models.Message, error) {
+	if offset < 0 || limit < 0 {
+		return []models.Message{}, nil
+	}
+
+	query := `
+		SELECT id, sender_id, recipient_id, content, read_at, created_at
+		FROM messages
+		WHERE (sender_id = $1 AND recipient_id = $2) OR (sender_id = $2 AND recipient_id = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+		OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userId, otherUserId, limit*offset, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMessages(rows)
+}
+
+func (s *SQLite) FindUnreadMessages(ctx context.Context, recipientId int64) ([]models.Message, error) {
+	query := `
+		SELECT id, sender_id, recipient_id, content, read_at, created_at
+		FROM messages
+		WHERE recipient_id = $1 AND read_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, recipientId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMessages(rows)
+}
+
+func (s *SQLite) MarkMessagesAsRead(ctx context.Context, recipientId int64, senderId int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE messages SET read_at = $1 WHERE recipient_id = $2 AND sender_id = $3 AND read_at IS NULL", time.Now().UTC(), recipientId, senderId)
+
+	return err
+}
+
+func scanMessages(rows *sql.Rows) ([]models.Message, error) {
+	defer rows.Close()
+
+	messages := []models.Message{}
+
+	for rows.Next() {
+		var m models.Message
+
+		err := rows.Scan(&m.ID, &m.SenderID, &m.RecipientID, &m.Content, &m.ReadAt, &m.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) SaveMFASecret(ctx context.Context, userId int64, secret string) error {
+	query := `
+		INSERT INTO mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = $2, confirmed_at = NULL, last_step = 0
+	`
+
+	_, err := s.db.ExecContext(ctx, query, userId, secret)
+
+	return err
+}
+
+func (s *SQLite) FindMFA(ctx context.Context, userId int64) (*models.MFA, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT user_id, secret, confirmed_at, last_step FROM mfa WHERE user_id = $1", userId)
+
+	var mfa models.MFA
+
+	err := row.Scan(&mfa.UserID, &mfa.Secret, &mfa.ConfirmedAt, &mfa.LastStep)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &mfa, nil
+}
+
+func (s *SQLite) ConfirmMFA(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE mfa SET confirmed_at = $1 WHERE user_id = $2", time.Now().UTC(), userId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userId, hash)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) DeleteMFA(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM mfa WHERE user_id = $1", userId)
+
+	return err
+}
+
+func (s *SQLite) UseMFAStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE mfa SET last_step = $2 WHERE user_id = $1 AND last_step < $2", userId, step)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (s *SQLite) UseRecoveryCode(ctx context.Context, userId int64, hash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE mfa_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL", time.Now().UTC(), userId, hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,8 @@
+CREATE TABLE "users" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "email" VARCHAR(255) NOT NULL UNIQUE,
+  "password" VARCHAR(255) NOT NULL,
+  "role" VARCHAR(20) NOT NULL DEFAULT 'user' CHECK ("role" IN ('user', 'moderator', 'admin')),
+  "email_verified_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
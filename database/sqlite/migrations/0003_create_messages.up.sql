@@ -0,0 +1,12 @@
+CREATE TABLE "messages" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "sender_id" INT NOT NULL,
+  "recipient_id" INT NOT NULL,
+  "content" TEXT NOT NULL,
+  "read_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY ("sender_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("recipient_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "messages_conversation_idx" ON "messages" ("sender_id", "recipient_id", "created_at");
@@ -0,0 +1,13 @@
+CREATE TABLE "sessions" (
+  "id" VARCHAR(64) PRIMARY KEY,
+  "user_id" INT NOT NULL,
+  "user_agent" TEXT NOT NULL,
+  "ip" VARCHAR(64) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "last_seen_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "expires_at" TIMESTAMP NOT NULL,
+  "revoked_at" TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "sessions_user_idx" ON "sessions" ("user_id");
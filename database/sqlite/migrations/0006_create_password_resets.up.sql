@@ -0,0 +1,9 @@
+CREATE TABLE "password_resets" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "user_id" INT NOT NULL,
+  "token_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "expires_at" TIMESTAMP NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+CREATE TABLE "api_keys" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "user_id" INT NOT NULL,
+  "name" VARCHAR(100) NOT NULL,
+  "prefix" VARCHAR(20) NOT NULL,
+  "key_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "scopes" TEXT NOT NULL DEFAULT '[]',
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "expires_at" TIMESTAMP,
+  "last_used_at" TIMESTAMP,
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "api_keys_user_idx" ON "api_keys" ("user_id");
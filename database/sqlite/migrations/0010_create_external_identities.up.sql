@@ -0,0 +1,10 @@
+CREATE TABLE "external_identities" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "user_id" INT NOT NULL,
+  "issuer" VARCHAR(255) NOT NULL,
+  "subject" VARCHAR(255) NOT NULL,
+  "email" VARCHAR(255) NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE ("issuer", "subject"),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
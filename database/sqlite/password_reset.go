@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id", reset.UserID, reset.TokenHash, reset.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&reset.ID))
+}
+
+func (s *SQLite) FindPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordReset, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, user_id, token_hash, expires_at, used_at FROM password_resets WHERE token_hash = $1", hash)
+
+	var reset models.PasswordReset
+
+	err := row.Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &reset.UsedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &reset, nil
+}
+
+func (s *SQLite) UsePasswordReset(ctx context.Context, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE password_resets SET used_at = $1 WHERE id = $2 AND used_at IS NULL", time.Now().UTC(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreatePost(ctx context.Context, post *models.Post) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO posts (title, content, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $4) RETURNING id, created_at, updated_at", post.Title, post.Content, post.UserID, time.Now().UTC())
+
+	return translateError(row.Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt))
+}
+
+func (s *SQLite) FindAllPosts(ctx context.Context) ([]models.Post, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, title, content, user_id, created_at, updated_at FROM posts")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []models.Post{}
+
+	for rows.Next() {
+		var post models.Post
+
+		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+func (s *SQLite) FindPostById(ctx context.Context, id int64) (*models.Post, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, title, content, user_id, created_at, updated_at FROM posts WHERE id = $1", id)
+
+	var post models.Post
+
+	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &post, nil
+}
+
+func (s *SQLite) FindPostsByUserId(ctx context.Context, userId int64) ([]models.Post, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, title, content, user_id, created_at, updated_at FROM posts WHERE user_id = $1", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []models.Post{}
+
+	for rows.Next() {
+		var post models.Post
+
+		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, &post.CreatedAt, &post.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+func (s *SQLite) UpdatePost(ctx context.Context, post *models.Post) error {
+	return checkAffected(s.db.ExecContext(ctx, "UPDATE posts SET title = $1, content = $2, updated_at = $3 WHERE id = $4", post.Title, post.Content, time.Now().UTC(), post.ID))
+}
+
+func (s *SQLite) DeletePost(ctx context.Context, id int64) error {
+	return checkAffected(s.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", id))
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateSession(ctx context.Context, session *models.Session) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO sessions (id, user_id, user_agent, ip, expires_at, created_at, last_seen_at) VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING created_at, last_seen_at", session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt.UTC(), time.Now().UTC())
+
+	return translateError(row.Scan(&session.CreatedAt, &session.LastSeenAt))
+}
+
+func (s *SQLite) FindSessionById(ctx context.Context, id string) (*models.Session, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at FROM sessions WHERE id = $1", id)
+
+	var session models.Session
+
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &session, nil
+}
+
+func (s *SQLite) ListActiveSessions(ctx context.Context, userId int64) ([]models.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userId, time.Now().UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []models.Session{}
+
+	for rows.Next() {
+		var session models.Session
+
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity at most once a minute, so authenticated
+// requests don't all turn into writes.
+func (s *SQLite) TouchSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET last_seen_at = $1 WHERE id = $2 AND last_seen_at < $3", time.Now().UTC(), id, time.Now().Add(-time.Minute).UTC())
+
+	return err
+}
+
+func (s *SQLite) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET last_seen_at = $1, expires_at = $2 WHERE id = $3", time.Now().UTC(), expiresAt.UTC(), id)
+
+	return err
+}
+
+func (s *SQLite) RevokeSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now().UTC(), id)
+
+	return err
+}
+
+func (s *SQLite) RevokeUserSessions(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL", time.Now().UTC(), userId)
+
+	return err
+}
@@ -0,0 +1,78 @@
+// Package sqlite implements the repositories on SQLite, with a driver written
+// in pure Go, for local development and small deployments.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+	"tincho.dev/rest-ws/migrate"
+)
+
+// MigrationsDir is where `migrate create` adds new migrations.
+const MigrationsDir = "database/sqlite/migrations"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite opens the database of a url like sqlite://rest-ws.db or
+// sqlite:///var/lib/rest-ws.db, creating the file if it doesn't exist.
+func NewSQLite(databaseURL string) (*SQLite, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(databaseURL, "sqlite:"), "//"), "?")
+
+	params, err := url.ParseQuery(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Foreign keys are off by default in SQLite, and they are needed to
+	// delete everything a user owns with them.
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", path+"?"+params.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and every connection to :memory: would
+	// open a different database.
+	db.SetMaxOpenConns(1)
+
+	return &SQLite{db: db}, nil
+}
+
+func Migrations() fs.FS {
+	sub, _ := fs.Sub(migrations, "migrations")
+
+	return sub
+}
+
+func (s *SQLite) Migrator() (*migrate.Migrator, error) {
+	return migrate.New(s.db, Migrations(), migrationLock{})
+}
+
+// migrationLock doesn't lock anything, as SQLite has no advisory locks. When
+// two processes apply the same migration anyway, the second one fails, as the
+// tables and the version it adds already exist, and is rolled back.
+type migrationLock struct{}
+
+func (migrationLock) Lock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
+
+func (migrationLock) Unlock(ctx context.Context, conn *sql.Conn) error {
+	return nil
+}
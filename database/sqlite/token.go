@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	row := s.db.QueryRowContext(ctx, "INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id", token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&token.ID))
+}
+
+func (s *SQLite) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at FROM refresh_tokens WHERE token_hash = $1", hash)
+
+	var t models.RefreshToken
+
+	err := row.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RotatedAt, &t.RevokedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &t, nil
+}
+
+func (s *SQLite) RotateRefreshToken(ctx context.Context, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET rotated_at = $1 WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL", time.Now().UTC(), id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (s *SQLite) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL", time.Now().UTC(), familyId)
+
+	return err
+}
+
+func (s *SQLite) RevokeUserRefreshTokens(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL", time.Now().UTC(), userId)
+
+	return err
+}
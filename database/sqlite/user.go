@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (s *SQLite) CreateUser(ctx context.Context, user *models.User) error {
+	if user.Role == "" {
+		user.Role = models.UserRole
+	}
+
+	var emailVerifiedAt *time.Time
+
+	if user.EmailVerifiedAt != nil {
+		verifiedAt := user.EmailVerifiedAt.UTC()
+		emailVerifiedAt = &verifiedAt
+	}
+
+	row := s.db.QueryRowContext(ctx, "INSERT INTO users (email, password, role, email_verified_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id", user.Email, user.Password, user.Role, emailVerifiedAt, time.Now().UTC())
+
+	return translateError(row.Scan(&user.Id))
+}
+
+func (s *SQLite) ListUsers(ctx context.Context, offset int64, limit int64) ([]models.User, error) {
+	if offset < 0 || limit < 0 {
+		return []models.User{}, nil
+	}
+
+	query := `
+		SELECT id, email, password, role, email_verified_at
+		FROM users
+		ORDER BY id
+		LIMIT $2
+		OFFSET $1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit*offset, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		var u models.User
+
+		err := rows.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+func (s *SQLite) FindAllUsers(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT id, email, password, role, email_verified_at
+		FROM users
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		var u models.User
+
+		err := rows.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+func (s *SQLite) FindUserById(ctx context.Context, id int64) (*models.User, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, email, password, role, email_verified_at FROM users WHERE id = $1", id)
+
+	var u models.User
+
+	err := row.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	posts, err := s.FindPostsByUserId(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u.Posts = posts
+
+	return &u, nil
+}
+
+func (s *SQLite) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id, email, password, role, email_verified_at FROM users WHERE email = $1", email)
+
+	var u models.User
+
+	err := row.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &u, nil
+}
+
+func (s *SQLite) UpdateOneUser(ctx context.Context, user *models.User) error {
+	var emailVerifiedAt *time.Time
+
+	if user.EmailVerifiedAt != nil {
+		verifiedAt := user.EmailVerifiedAt.UTC()
+		emailVerifiedAt = &verifiedAt
+	}
+
+	return checkAffected(s.db.ExecContext(ctx, "UPDATE users SET email = $1, password = $2, role = $3, email_verified_at = $4 WHERE id = $5", user.Email, user.Password, user.Role, emailVerifiedAt, user.Id))
+}
+
+func (s *SQLite) DeleteOneUser(ctx context.Context, id int64) error {
+	return checkAffected(s.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id))
+}
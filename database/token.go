@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (p *Postgres) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	row := p.db.QueryRowContext(ctx, "INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id", token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt.UTC())
+
+	return translateError(row.Scan(&token.ID))
+}
+
+func (p *Postgres) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at FROM refresh_tokens WHERE token_hash = $1", hash)
+
+	var t models.RefreshToken
+
+	err := row.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RotatedAt, &t.RevokedAt)
+
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &t, nil
+}
+
+func (p *Postgres) RotateRefreshToken(ctx context.Context, id int64) (bool, error) {
+	result, err := p.db.ExecContext(ctx, "UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL", id)
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (p *Postgres) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyId)
+
+	return err
+}
+
+func (p *Postgres) RevokeUserRefreshTokens(ctx context.Context, userId int64) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userId)
+
+	return err
+}
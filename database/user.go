@@ -2,20 +2,39 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"tincho.dev/rest-ws/models"
 )
 
 func (p *Postgres) CreateUser(ctx context.Context, user *models.User) error {
-	_, err := p.db.ExecContext(ctx, "INSERT INTO users (email, password) VALUES ($1, $2)", user.Email, user.Password)
+	if user.Role == "" {
+		user.Role = models.UserRole
+	}
+
+	var emailVerifiedAt *time.Time
+
+	if user.EmailVerifiedAt != nil {
+		verifiedAt := user.EmailVerifiedAt.UTC()
+		emailVerifiedAt = &verifiedAt
+	}
+
+	row := p.db.QueryRowContext(ctx, "INSERT INTO users (email, password, role, email_verified_at) VALUES ($1, $2, $3, $4) RETURNING id", user.Email, user.Password, user.Role, emailVerifiedAt)
 
-	return err
+	return translateError(row.Scan(&user.Id))
 }
 
 func (p *Postgres) ListUsers(ctx context.Context, offset int64, limit int64) ([]models.User, error) {
+	// Postgres rejects a negative OFFSET or LIMIT, so the backends agree on
+	// answering no rows instead.
+	if offset < 0 || limit < 0 {
+		return []models.User{}, nil
+	}
+
 	query := `
-		SELECT id, email, password
+		SELECT id, email, password, role, email_verified_at
 		FROM users
+		ORDER BY id
 		OFFSET $1
 		LIMIT $2
 	`
@@ -33,7 +52,7 @@ func (p *Postgres) ListUsers(ctx context.Context, offset int64, limit int64) ([]
 	for rows.Next() {
 		var u models.User
 
-		err := rows.Scan(&u.Id, &u.Email, &u.Password)
+		err := rows.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
 
 		if err != nil {
 			return nil, err
@@ -47,7 +66,7 @@ func (p *Postgres) ListUsers(ctx context.Context, offset int64, limit int64) ([]
 
 func (p *Postgres) FindAllUsers(ctx context.Context) ([]models.User, error) {
 	query := `
-		SELECT id, email, password
+		SELECT id, email, password, role, email_verified_at
 		FROM users
 	`
 
@@ -64,7 +83,7 @@ func (p *Postgres) FindAllUsers(ctx context.Context) ([]models.User, error) {
 	for rows.Next() {
 		var u models.User
 
-		err := rows.Scan(&u.Id, &u.Email, &u.Password)
+		err := rows.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
 
 		if err != nil {
 			return nil, err
@@ -77,14 +96,14 @@ func (p *Postgres) FindAllUsers(ctx context.Context) ([]models.User, error) {
 }
 
 func (p *Postgres) FindUserById(ctx context.Context, id int64) (*models.User, error) {
-	row := p.db.QueryRowContext(ctx, "SELECT id, email, password FROM users WHERE id = $1", id)
+	row := p.db.QueryRowContext(ctx, "SELECT id, email, password, role, email_verified_at FROM users WHERE id = $1", id)
 
 	var u models.User
 
-	err := row.Scan(&u.Id, &u.Email, &u.Password)
+	err := row.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	posts, err := p.FindPostsByUserId(ctx, id)
@@ -99,27 +118,30 @@ func (p *Postgres) FindUserById(ctx context.Context, id int64) (*models.User, er
 }
 
 func (p *Postgres) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	row := p.db.QueryRowContext(ctx, "SELECT id, email, password FROM users WHERE email = $1", email)
+	row := p.db.QueryRowContext(ctx, "SELECT id, email, password, role, email_verified_at FROM users WHERE email = $1", email)
 
 	var u models.User
 
-	err := row.Scan(&u.Id, &u.Email, &u.Password)
+	err := row.Scan(&u.Id, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &u, nil
 }
 
 func (p *Postgres) UpdateOneUser(ctx context.Context, user *models.User) error {
-	_, err := p.db.ExecContext(ctx, "UPDATE users SET email = $1, password = $2 WHERE id = $3", user.Email, user.Password, user.Id)
+	var emailVerifiedAt *time.Time
 
-	return err
+	if user.EmailVerifiedAt != nil {
+		verifiedAt := user.EmailVerifiedAt.UTC()
+		emailVerifiedAt = &verifiedAt
+	}
+
+	return checkAffected(p.db.ExecContext(ctx, "UPDATE users SET email = $1, password = $2, role = $3, email_verified_at = $4 WHERE id = $5", user.Email, user.Password, user.Role, emailVerifiedAt, user.Id))
 }
 
 func (p *Postgres) DeleteOneUser(ctx context.Context, id int64) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
-
-	return err
+	return checkAffected(p.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id))
 }
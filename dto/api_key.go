@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// CreateAPIKey DTOs
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"dive,oneof=read write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse is the only response that includes the key itself.
+type CreateAPIKeyResponse struct {
+	Key string `json:"key"`
+	APIKeyResponse
+}
+
+// ListAPIKeys DTOs
+
+type APIKeyResponse struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// SendMessage DTOs
+
+type SendMessageRequest struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
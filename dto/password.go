@@ -0,0 +1,21 @@
+package dto
+
+// ForgotPassword DTOs
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPassword DTOs
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gt=8"`
+}
+
+// ChangePassword DTOs
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,gt=8"`
+}
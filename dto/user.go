@@ -22,14 +22,23 @@ type SignInRequest struct {
 }
 
 type SignInResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// RefreshToken DTOs
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // FindAllUsers DTOs
 
 type FindAllUsersResponse struct {
-	Id    int64  `json:"id"`
-	Email string `json:"email"`
+	Id     int64  `json:"id"`
+	Email  string `json:"email"`
+	Online bool   `json:"online"`
 }
 
 // FindOneUser DTOs
@@ -50,10 +59,24 @@ type UpdateUserResponse struct {
 	Email string `json:"email"`
 }
 
+// UpdateUserRole DTOs
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+type UpdateUserRoleResponse struct {
+	Id    int64  `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
 // GetMeData DTOs
 
 type GetMeDataResponse struct {
-	Id    int64         `json:"id"`
-	Email string        `json:"email"`
-	Posts []models.Post `json:"posts"`
+	Id            int64         `json:"id"`
+	Email         string        `json:"email"`
+	EmailVerified bool          `json:"email_verified"`
+	Role          string        `json:"role"`
+	Posts         []models.Post `json:"posts"`
 }
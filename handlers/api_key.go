@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/authz"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+// API_KEY_PREFIX_LENGTH is how much of a key is stored in clear, to tell keys
+// apart in listings.
+const API_KEY_PREFIX_LENGTH = len(models.APIKeyPrefix) + 8
+
+func apiKeyResponse(key *models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		Id:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+// signedIn reports whether the request was authenticated by signing in,
+// rather than with an API key. Keys can't manage keys, so a leaked key can't
+// be used to create new ones with wider scopes.
+func signedIn(w http.ResponseWriter, claims *models.AppClaims) bool {
+	if claims.APIKeyId != 0 {
+		writeRepositoryError(w, repositories.ErrForbidden, "API keys can't manage API keys")
+		return false
+	}
+
+	return true
+}
+
+func CreateAPIKeyHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		if !signedIn(w, claims) {
+			return
+		}
+
+		payload, err := utils.Validate[dto.CreateAPIKeyRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "expires_at must be in the future",
+			})
+			return
+		}
+
+		token, err := utils.GenerateRandomToken()
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		key := models.APIKeyPrefix + token
+
+		apiKey := &models.APIKey{
+			UserID:    claims.UserId,
+			Name:      payload.Name,
+			Prefix:    key[:API_KEY_PREFIX_LENGTH],
+			KeyHash:   utils.HashToken(key),
+			Scopes:    payload.Scopes,
+			ExpiresAt: payload.ExpiresAt,
+		}
+
+		if apiKey.Scopes == nil {
+			apiKey.Scopes = []string{}
+		}
+
+		err = repositories.CreateAPIKey(r.Context(), apiKey)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response := &dto.CreateAPIKeyResponse{
+			Key:            key,
+			APIKeyResponse: apiKeyResponse(apiKey),
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func ListAPIKeysHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		if !signedIn(w, claims) {
+			return
+		}
+
+		keys, err := repositories.ListAPIKeys(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response := make([]dto.APIKeyResponse, 0, len(keys))
+
+		for _, key := range keys {
+			response = append(response, apiKeyResponse(&key))
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func DeleteAPIKeyHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		if !signedIn(w, claims) {
+			return
+		}
+
+		keyId, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid request",
+			})
+			return
+		}
+
+		key, err := repositories.FindAPIKeyById(r.Context(), keyId)
+
+		// Other users' keys are reported as missing so their ids can't be probed.
+		if err != nil || !authz.Can(r.Context(), claims, authz.DeleteAction, authz.APIKey(key)) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "API key not found",
+			})
+			return
+		}
+
+		err = repositories.DeleteAPIKey(r.Context(), key.ID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "API key deleted",
+		})
+	}
+}
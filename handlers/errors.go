@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tincho.dev/rest-ws/repositories"
+)
+
+// repositoryErrorStatus maps an error of the repositories to the status of
+// the response: 404 for ErrNotFound, 409 for ErrConflict, 403 for
+// ErrForbidden and 500 for anything else.
+func repositoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrForbidden):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeRepositoryError answers with the status of an error of the
+// repositories. Unexpected errors aren't shown, the message is sent
+// instead, or nothing if it is empty. A denial sends the message too, to
+// explain itself, and other known errors their status text.
+func writeRepositoryError(w http.ResponseWriter, err error, message string) {
+	status := repositoryErrorStatus(err)
+	w.WriteHeader(status)
+
+	if status != http.StatusInternalServerError && (status != http.StatusForbidden || message == "") {
+		message = http.StatusText(status)
+	}
+
+	if message == "" {
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/server"
+)
+
+// EventsHandler streams post events to clients that can't use WebSockets.
+// EventSource can't set headers either, so the token may also be sent as the
+// "token" query parameter.
+func EventsHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString := r.URL.Query().Get("token")
+
+		if tokenString == "" {
+			tokenString = strings.Replace(r.Header.Get("Authorization"), "Bearer ", "", 1)
+		}
+
+		claims, err := middlewares.ParseToken(r.Context(), s, tokenString)
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		s.Events().HandleSSE(w, r, claims)
+	}
+}
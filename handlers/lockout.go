@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"tincho.dev/rest-ws/authz"
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+)
+
+// beginAttempt counts an attempt for the keys as failed, so it has to be
+// forgotten if it succeeds. It rejects the request with a 429 when any of the
+// keys is locked after too many failed attempts, and writes the error response
+// when it fails.
+func beginAttempt(w http.ResponseWriter, r *http.Request, keys ...lockout.Key) (*lockout.Attempt, bool) {
+	attempt, retryAfter, err := lockout.Begin(r.Context(), keys...)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if retryAfter > 0 {
+		// Rejected attempts don't make the lockout longer.
+		if err := attempt.Forget(r.Context()); err != nil {
+			log.Println("Error forgetting rejected sign in: ", err)
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Too many failed attempts, try again later",
+		})
+		return nil, false
+	}
+
+	return attempt, true
+}
+
+func UnlockUserHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		userId, ok := targetUserId(w, r, claims, authz.UnlockAction)
+
+		if !ok {
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), userId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		err = lockout.Reset(r.Context(), lockout.Account(user.Email), lockout.MFA(user.Id))
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "User unlocked",
+		})
+	}
+}
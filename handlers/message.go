@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/pubsub"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+func SendMessageHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		recipientIdParam := mux.Vars(r)["id"]
+		recipientId, err := strconv.ParseInt(recipientIdParam, 10, 64)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid request",
+			})
+			return
+		}
+
+		payload, err := utils.Validate[dto.SendMessageRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		if recipientId == claims.UserId {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Cannot send a message to yourself",
+			})
+			return
+		}
+
+		_, err = repositories.FindUserById(r.Context(), recipientId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		message := &models.Message{
+			SenderID:    claims.UserId,
+			RecipientID: recipientId,
+			Content:     payload.Content,
+		}
+
+		err = repositories.CreateMessage(r.Context(), message)
+
+		if err != nil {
+			writeRepositoryError(w, err, "Error sending message")
+			return
+		}
+
+		// The sender's other tabs are kept in sync too.
+		err = pubsub.Publish(r.Context(), &models.Notification{
+			Recipients: []int64{message.RecipientID, message.SenderID},
+			Event: models.Event{
+				Type:    models.MessageCreatedEvent,
+				Payload: message,
+			},
+		})
+
+		if err != nil {
+			log.Println("Error publishing message event: ", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(message)
+	}
+}
+
+func ListMessagesHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		otherUserIdParam := mux.Vars(r)["id"]
+		otherUserId, err := strconv.ParseInt(otherUserIdParam, 10, 64)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		offset, limit, err := utils.GetPagination(r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		messages, err := repositories.ListMessages(r.Context(), claims.UserId, otherUserId, offset, limit)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		// Reading the conversation acknowledges what the other user sent.
+		err = repositories.MarkMessagesAsRead(r.Context(), claims.UserId, otherUserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(messages)
+	}
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/totp"
+	"tincho.dev/rest-ws/utils"
+)
+
+const (
+	MFA_ISSUER                    = "rest-ws"
+	MFA_CHALLENGE_EXPIRATION_TIME = time.Minute * 5
+	RECOVERY_CODES                = 10
+)
+
+var errInvalidMFAToken = errors.New("invalid mfa token")
+
+// generateRecoveryCodes returns codes such as "k3jd9-x8mq2", with 50 bits of
+// entropy each.
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RECOVERY_CODES)
+
+	for i := range codes {
+		b := make([]byte, 7)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which are easy to get
+// wrong when typing a code.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return utils.HashToken(code)
+}
+
+// verifyMFACode accepts either a TOTP code that hasn't been used yet or one
+// of the user's unused recovery codes.
+func verifyMFACode(ctx context.Context, mfa *models.MFA, code string) (bool, error) {
+	if step, ok := totp.Validate(mfa.Secret, code, time.Now()); ok {
+		return repositories.UseMFAStep(ctx, mfa.UserID, step)
+	}
+
+	return repositories.UseRecoveryCode(ctx, mfa.UserID, hashRecoveryCode(code))
+}
+
+// issueMFAChallenge signs the token that lets the user finish signing in
+// with /signin/mfa.
+func issueMFAChallenge(s server.Server, user *models.User) (*dto.MFAChallengeResponse, error) {
+	now := time.Now()
+
+	claims := &models.MFAChallengeClaims{
+		UserId: user.Id,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  models.MFAChallengeAudience,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(MFA_CHALLENGE_EXPIRATION_TIME).Unix(),
+		},
+	}
+
+	signedToken, err := s.Keys().Sign(claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    signedToken,
+		ExpiresIn:   int64(MFA_CHALLENGE_EXPIRATION_TIME.Seconds()),
+	}, nil
+}
+
+func parseMFAChallenge(s server.Server, tokenString string) (*models.MFAChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &models.MFAChallengeClaims{}, s.Keys().Keyfunc)
+
+	if err != nil || !token.Valid {
+		return nil, errInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(*models.MFAChallengeClaims)
+
+	if !ok || claims.Audience != models.MFAChallengeAudience {
+		return nil, errInvalidMFAToken
+	}
+
+	return claims, nil
+}
+
+func EnrollMFAHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		mfa, err := repositories.FindMFA(r.Context(), claims.UserId)
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if err == nil && mfa.ConfirmedAt != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Two-factor authentication is already enabled",
+			})
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = repositories.SaveMFASecret(r.Context(), user.Id, secret)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response := &dto.MFAEnrollResponse{
+			Secret: secret,
+			URI:    totp.URI(MFA_ISSUER, user.Email, secret),
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func ConfirmMFAHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		payload, err := utils.Validate[dto.MFACodeRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		mfa, err := repositories.FindMFA(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if mfa.ConfirmedAt != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Two-factor authentication is already enabled",
+			})
+			return
+		}
+
+		step, ok := totp.Validate(mfa.Secret, payload.Code, time.Now())
+
+		if ok {
+			ok, err = repositories.UseMFAStep(r.Context(), claims.UserId, step)
+
+			if err != nil {
+				writeRepositoryError(w, err, "")
+				return
+			}
+		}
+
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid code",
+			})
+			return
+		}
+
+		codes, err := generateRecoveryCodes()
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		hashes := make([]string, len(codes))
+
+		for i, code := range codes {
+			hashes[i] = hashRecoveryCode(code)
+		}
+
+		err = repositories.ConfirmMFA(r.Context(), claims.UserId, hashes)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&dto.MFAConfirmResponse{
+			RecoveryCodes: codes,
+		})
+	}
+}
+
+func DisableMFAHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		payload, err := utils.Validate[dto.MFACodeRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		mfa, err := repositories.FindMFA(r.Context(), claims.UserId)
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if err != nil || mfa.ConfirmedAt == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Two-factor authentication is not enabled",
+			})
+			return
+		}
+
+		ok, err := verifyMFACode(r.Context(), mfa, payload.Code)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid code",
+			})
+			return
+		}
+
+		err = repositories.DeleteMFA(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Two-factor authentication disabled",
+		})
+	}
+}
+
+func MFASignInHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload, err := utils.Validate[dto.MFASignInRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		challenge, err := parseMFAChallenge(s, payload.MFAToken)
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		key := lockout.MFA(challenge.UserId)
+		ip := lockout.IP(utils.ClientIP(r))
+
+		attempt, ok := beginAttempt(w, r, key, ip)
+
+		if !ok {
+			return
+		}
+
+		mfa, err := repositories.FindMFA(r.Context(), challenge.UserId)
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if err != nil || mfa.ConfirmedAt == nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ok, err = verifyMFACode(r.Context(), mfa, payload.Code)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = attempt.Forget(r.Context())
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = lockout.Reset(r.Context(), key)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), challenge.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		sessionId, err := startSession(r, user.Id)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response, err := issueTokens(r.Context(), s, user, sessionId)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/oidc"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+const (
+	OIDC_STATE_COOKIE          = "oidc_state"
+	OIDC_STATE_EXPIRATION_TIME = time.Minute * 10
+)
+
+var (
+	errOIDCNoEmail    = errors.New("the provider didn't share an email")
+	errOIDCEmailTaken = errors.New("an account with this email already exists")
+)
+
+func oidcNotConfigured(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": "OIDC sign in is not configured",
+	})
+}
+
+// stateCookie keeps the state, nonce and PKCE verifier of a sign in until
+// the provider redirects back, in a token signed with the server keys.
+func stateCookie(s server.Server, r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     OIDC_STATE_COOKIE,
+		Value:    value,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(s.Config().AppURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func OIDCLoginHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := s.OIDC()
+
+		if provider == nil {
+			oidcNotConfigured(w)
+			return
+		}
+
+		claims := &models.OIDCStateClaims{
+			StandardClaims: jwt.StandardClaims{
+				Audience:  models.OIDCStateAudience,
+				ExpiresAt: time.Now().Add(OIDC_STATE_EXPIRATION_TIME).Unix(),
+			},
+		}
+
+		for _, value := range []*string{&claims.State, &claims.Nonce, &claims.Verifier} {
+			token, err := utils.GenerateRandomToken()
+
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			*value = token
+		}
+
+		signedState, err := s.Keys().Sign(claims)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, stateCookie(s, r, signedState, int(OIDC_STATE_EXPIRATION_TIME.Seconds())))
+		http.Redirect(w, r, provider.AuthCodeURL(claims.State, claims.Nonce, claims.Verifier), http.StatusFound)
+	}
+}
+
+func OIDCCallbackHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := s.OIDC()
+
+		if provider == nil {
+			oidcNotConfigured(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+
+		if query.Get("error") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": query.Get("error"),
+			})
+			return
+		}
+
+		cookie, err := r.Cookie(OIDC_STATE_COOKIE)
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// The state is single use.
+		http.SetCookie(w, stateCookie(s, r, "", -1))
+
+		state, err := parseOIDCState(s, cookie.Value)
+
+		if err != nil || subtle.ConstantTimeCompare([]byte(state.State), []byte(query.Get("state"))) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		token, err := provider.Exchange(r.Context(), query.Get("code"), state.Verifier)
+
+		if err != nil {
+			log.Println("Error exchanging oidc code: ", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		idToken, err := provider.Verify(r.Context(), token.IDToken, state.Nonce)
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := externalUser(r.Context(), provider, idToken)
+
+		if errors.Is(err, errOIDCNoEmail) || errors.Is(err, errOIDCEmailTaken) {
+			status := http.StatusBadRequest
+
+			if errors.Is(err, errOIDCEmailTaken) {
+				status = http.StatusConflict
+			}
+
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		completeSignIn(w, r, s, user)
+	}
+}
+
+func parseOIDCState(s server.Server, tokenString string) (*models.OIDCStateClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &models.OIDCStateClaims{}, s.Keys().Keyfunc)
+
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid oidc state")
+	}
+
+	claims, ok := token.Claims.(*models.OIDCStateClaims)
+
+	if !ok || claims.Audience != models.OIDCStateAudience {
+		return nil, errors.New("invalid oidc state")
+	}
+
+	return claims, nil
+}
+
+// externalUser returns the user linked to the provider account. The first
+// time, the account is linked to the user with the same email, when the
+// provider verified it, or to a new user.
+func externalUser(ctx context.Context, provider *oidc.Provider, idToken *oidc.IDToken) (*models.User, error) {
+	identity, err := repositories.FindExternalIdentity(ctx, provider.Issuer(), idToken.Subject)
+
+	if err == nil {
+		return repositories.FindUserById(ctx, identity.UserID)
+	}
+
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	if idToken.Email == "" {
+		return nil, errOIDCNoEmail
+	}
+
+	user, err := repositories.GetUserByEmail(ctx, idToken.Email)
+
+	switch {
+	case err == nil && !idToken.EmailVerified:
+		// Anyone can claim an address at some providers, which must not
+		// give them access to the account that owns it here.
+		return nil, errOIDCEmailTaken
+	case errors.Is(err, repositories.ErrNotFound):
+		user, err = createExternalUser(ctx, idToken)
+
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	err = repositories.CreateExternalIdentity(ctx, &models.ExternalIdentity{
+		UserID:  user.Id,
+		Issuer:  provider.Issuer(),
+		Subject: idToken.Subject,
+		Email:   idToken.Email,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createExternalUser signs up a user from their provider account. They get a
+// random password, so they can only sign in with the provider until they
+// reset it.
+func createExternalUser(ctx context.Context, idToken *oidc.IDToken) (*models.User, error) {
+	password, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:    idToken.Email,
+		Password: string(hashedPassword),
+	}
+
+	if idToken.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	err = repositories.CreateUser(ctx, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/mailer"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+const (
+	PASSWORD_RESET_EXPIRATION_TIME = time.Hour
+)
+
+// sendMail delivers the message in the background, so the response time
+// doesn't reveal whether an email was sent.
+func sendMail(message *mailer.Message) {
+	go func() {
+		if err := mailer.Send(context.Background(), message); err != nil {
+			log.Println("Error sending mail: ", err)
+		}
+	}()
+}
+
+func ForgotPasswordHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload, err := utils.Validate[dto.ForgotPasswordRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		// The response is the same whether the account exists or not, so this
+		// endpoint can't be used to find out which emails are registered.
+		response := map[string]string{
+			"message": "If the account exists, a reset email has been sent",
+		}
+
+		user, err := repositories.GetUserByEmail(r.Context(), payload.Email)
+
+		if err != nil {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		token, err := utils.GenerateRandomToken()
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = repositories.CreatePasswordReset(r.Context(), &models.PasswordReset{
+			UserID:    user.Id,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(PASSWORD_RESET_EXPIRATION_TIME),
+		})
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		sendMail(&mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"Someone asked to reset the password of your account.\n\n"+
+					"Use this token with POST %s/password/reset within the next hour:\n\n%s\n\n"+
+					"If it wasn't you, you can ignore this email.",
+				s.Config().AppURL, token,
+			),
+		})
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func ResetPasswordHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload, err := utils.Validate[dto.ResetPasswordRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		err = s.Config().PasswordPolicy.Check(payload.Password)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		reset, err := repositories.FindPasswordResetByHash(r.Context(), utils.HashToken(payload.Token))
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if err != nil || reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid or expired token",
+			})
+			return
+		}
+
+		used, err := repositories.UsePasswordReset(r.Context(), reset.ID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if !used {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid or expired token",
+			})
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), reset.UserID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		user.Password = string(hashedPassword)
+
+		err = repositories.UpdateOneUser(r.Context(), user)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		// Whoever knew the old password shouldn't stay signed in, nor keep
+		// using an API key created with it.
+		err = revokeAllSessions(r.Context(), user.Id)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		err = repositories.DeleteUserAPIKeys(r.Context(), user.Id)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Password updated",
+		})
+	}
+}
+
+func ChangePasswordHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		payload, err := utils.Validate[dto.ChangePasswordRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		err = s.Config().PasswordPolicy.Check(payload.NewPassword)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.CurrentPassword))
+
+		if err != nil {
+			writeRepositoryError(w, repositories.ErrForbidden, "Current password is incorrect")
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), bcrypt.DefaultCost)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		user.Password = string(hashedPassword)
+
+		err = repositories.UpdateOneUser(r.Context(), user)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		err = endOtherSessions(r.Context(), claims)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Password updated",
+		})
+	}
+}
@@ -1,20 +1,40 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/authz"
 	"tincho.dev/rest-ws/dto"
 	"tincho.dev/rest-ws/middlewares"
 	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/pubsub"
 	"tincho.dev/rest-ws/repositories"
 	"tincho.dev/rest-ws/server"
 	"tincho.dev/rest-ws/utils"
 )
 
+// publishPostEvent notifies the WebSocket subscribers and Server-Sent Events
+// clients of every server instance about a change to a post.
+func publishPostEvent(ctx context.Context, eventType string, post *models.Post) {
+	err := pubsub.Publish(ctx, &models.Notification{
+		Topics: models.PostTopics(post),
+		Event: models.Event{
+			Type:    eventType,
+			Payload: post,
+		},
+	})
+
+	if err != nil {
+		log.Println("Error publishing post event: ", err)
+	}
+}
+
 func CreatePostHandler(s server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/json")
@@ -35,10 +55,12 @@ func CreatePostHandler(s server.Server) http.HandlerFunc {
 		fmt.Println(claims.UserId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error finding user",
-			})
+			writeRepositoryError(w, err, "Error finding user")
+			return
+		}
+
+		if s.Config().RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+			writeRepositoryError(w, repositories.ErrForbidden, "Email not verified")
 			return
 		}
 
@@ -51,13 +73,12 @@ func CreatePostHandler(s server.Server) http.HandlerFunc {
 		err = repositories.CreatePost(r.Context(), post)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error creating post",
-			})
+			writeRepositoryError(w, err, "Error creating post")
 			return
 		}
 
+		publishPostEvent(r.Context(), models.PostCreatedEvent, post)
+
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(post)
 	}
@@ -70,7 +91,7 @@ func FindAllPostsHandler(s server.Server) http.HandlerFunc {
 		posts, err := repositories.FindAllPosts(r.Context())
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
@@ -97,10 +118,7 @@ func FindOnePostHandler(s server.Server) http.HandlerFunc {
 		post, err := repositories.FindPostById(r.Context(), postId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error finding post",
-			})
+			writeRepositoryError(w, err, "Error finding post")
 			return
 		}
 
@@ -140,19 +158,12 @@ func UpdateOnePostHandler(s server.Server) http.HandlerFunc {
 		post, err := repositories.FindPostById(r.Context(), postId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error finding post",
-			})
+			writeRepositoryError(w, err, "Error finding post")
 			return
 		}
 
-		if post.UserID != claims.UserId {
-			fmt.Println(post.UserID, claims.UserId)
-			w.WriteHeader(http.StatusForbidden)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Forbidden",
-			})
+		if !authz.Can(r.Context(), claims, authz.UpdateAction, authz.Post(post)) {
+			writeRepositoryError(w, repositories.ErrForbidden, "")
 			return
 		}
 
@@ -162,13 +173,12 @@ func UpdateOnePostHandler(s server.Server) http.HandlerFunc {
 		err = repositories.UpdatePost(r.Context(), post)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error updating post",
-			})
+			writeRepositoryError(w, err, "Error updating post")
 			return
 		}
 
+		publishPostEvent(r.Context(), models.PostUpdatedEvent, post)
+
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(post)
 	}
@@ -194,31 +204,24 @@ func DeleteOnePostHandler(s server.Server) http.HandlerFunc {
 		post, err := repositories.FindPostById(r.Context(), postId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error finding post",
-			})
+			writeRepositoryError(w, err, "Error finding post")
 			return
 		}
 
-		if post.UserID != claims.UserId {
-			w.WriteHeader(http.StatusForbidden)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Forbidden",
-			})
+		if !authz.Can(r.Context(), claims, authz.DeleteAction, authz.Post(post)) {
+			writeRepositoryError(w, repositories.ErrForbidden, "")
 			return
 		}
 
 		err = repositories.DeletePost(r.Context(), post.ID)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Error deleting post",
-			})
+			writeRepositoryError(w, err, "Error deleting post")
 			return
 		}
 
+		publishPostEvent(r.Context(), models.PostDeletedEvent, post)
+
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
 			"message": "Post deleted",
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/authz"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/pubsub"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/revocation"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+// startSession records a new sign in from the request's client and returns
+// its id.
+func startSession(r *http.Request, userId int64) (string, error) {
+	sessionId, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	err = repositories.CreateSession(r.Context(), &models.Session{
+		ID:        sessionId,
+		UserID:    userId,
+		UserAgent: r.UserAgent(),
+		IP:        utils.ClientIP(r),
+		ExpiresAt: time.Now().Add(REFRESH_EXPIRATION_TIME),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return sessionId, nil
+}
+
+// endSession revokes a session together with its refresh tokens and the
+// access tokens that are still valid, and closes its connections.
+func endSession(ctx context.Context, userId int64, sessionId string) error {
+	err := repositories.RevokeSession(ctx, sessionId)
+
+	if err != nil {
+		return err
+	}
+
+	err = repositories.RevokeRefreshTokenFamily(ctx, sessionId)
+
+	if err != nil {
+		return err
+	}
+
+	err = revocation.RevokeToken(ctx, sessionId, time.Now().Add(EXPIRATION_TIME))
+
+	if err != nil {
+		return err
+	}
+
+	closeRevokedConnections(ctx, userId)
+
+	return nil
+}
+
+// revokeAllSessions ends every session of the user and revokes every token
+// issued to them so far. API keys are kept. The sessions are revoked one by one too,
+// as RevokeUserTokens spares the tokens issued in its same second.
+func revokeAllSessions(ctx context.Context, userId int64) error {
+	err := revocation.RevokeUserTokens(ctx, userId, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	closeRevokedConnections(ctx, userId)
+
+	err = repositories.RevokeUserRefreshTokens(ctx, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return repositories.RevokeUserSessions(ctx, userId)
+}
+
+// closeRevokedConnections asks every instance to close the WebSocket and
+// Server-Sent Events connections the user opened with a token that is now
+// revoked.
+func closeRevokedConnections(ctx context.Context, userId int64) {
+	err := pubsub.Publish(ctx, &models.Notification{
+		Recipients: []int64{userId},
+		Event:      models.Event{Type: models.TokensRevokedEvent},
+	})
+
+	if err != nil {
+		log.Println("Error publishing revocation: ", err)
+	}
+}
+
+// endOtherSessions ends every session of the user except the one the claims
+// belong to. Tokens issued without a session can't be told apart, so they
+// are all revoked.
+func endOtherSessions(ctx context.Context, claims *models.AppClaims) error {
+	if claims.SessionId == "" {
+		return revokeAllSessions(ctx, claims.UserId)
+	}
+
+	sessions, err := repositories.ListActiveSessions(ctx, claims.UserId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == claims.SessionId {
+			continue
+		}
+
+		err = endSession(ctx, claims.UserId, session.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ListSessionsHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		sessions, err := repositories.ListActiveSessions(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response := make([]dto.SessionResponse, 0, len(sessions))
+
+		for _, session := range sessions {
+			response = append(response, dto.SessionResponse{
+				Id:         session.ID,
+				UserAgent:  session.UserAgent,
+				IP:         session.IP,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				Current:    session.ID == claims.SessionId,
+			})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func DeleteSessionHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+		sessionId := mux.Vars(r)["id"]
+
+		session, err := repositories.FindSessionById(r.Context(), sessionId)
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		// Other users' sessions are reported as missing so their ids can't be probed.
+		if err != nil || !authz.Can(r.Context(), claims, authz.DeleteAction, authz.Session(session)) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Session not found",
+			})
+			return
+		}
+
+		err = endSession(r.Context(), session.UserID, session.ID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Session deleted",
+		})
+	}
+}
+
+func RevokeAllSessionsHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		err := revokeAllSessions(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		err = repositories.DeleteUserAPIKeys(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "All sessions revoked",
+		})
+	}
+}
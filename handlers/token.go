@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/revocation"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+const (
+	EXPIRATION_TIME         = time.Minute * 15
+	REFRESH_EXPIRATION_TIME = time.Hour * 24 * 30
+)
+
+// issueTokens signs a short-lived access token and stores a new refresh token
+// in the given family, which also identifies the sign in both belong to.
+func issueTokens(ctx context.Context, s server.Server, user *models.User, familyId string) (*dto.SignInResponse, error) {
+	jti, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	claims := &models.AppClaims{
+		UserId:         user.Id,
+		SessionId:      familyId,
+		Role:           user.Role,
+		IssuedAtMicros: now.UnixMicro(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(EXPIRATION_TIME).Unix(),
+		},
+	}
+
+	signedToken, err := s.Keys().Sign(claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = repositories.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    user.Id,
+		FamilyID:  familyId,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(REFRESH_EXPIRATION_TIME),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SignInResponse{
+		Token:        signedToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(EXPIRATION_TIME.Seconds()),
+	}, nil
+}
+
+func RefreshTokenHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		payload, err := utils.Validate[dto.RefreshTokenRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		token, err := repositories.FindRefreshTokenByHash(r.Context(), utils.HashToken(payload.RefreshToken))
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if err != nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rotated := false
+
+		if token.RotatedAt == nil {
+			rotated, err = repositories.RotateRefreshToken(r.Context(), token.ID)
+
+			if err != nil {
+				writeRepositoryError(w, err, "")
+				return
+			}
+		}
+
+		// The token was already exchanged, so either it leaked or the client
+		// is replaying it: revoke every token descending from the same sign in.
+		if !rotated {
+			err = endSession(r.Context(), token.UserID, token.FamilyID)
+
+			if err != nil {
+				writeRepositoryError(w, err, "")
+				return
+			}
+
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// The user is read again so role changes apply from the next refresh.
+		user, err := repositories.FindUserById(r.Context(), token.UserID)
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		response, err := issueTokens(r.Context(), s, user, token.FamilyID)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = repositories.ExtendSession(r.Context(), token.FamilyID, time.Now().Add(REFRESH_EXPIRATION_TIME))
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func SignOutHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		err := revocation.RevokeToken(r.Context(), claims.Id, time.Unix(claims.ExpiresAt, 0))
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if claims.SessionId != "" {
+			err = endSession(r.Context(), claims.UserId, claims.SessionId)
+
+			if err != nil {
+				writeRepositoryError(w, err, "")
+				return
+			}
+		} else {
+			closeRevokedConnections(r.Context(), claims.UserId)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Signed out",
+		})
+	}
+}
+
+func JWKSHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(s.Keys().JWKS())
+	}
+}
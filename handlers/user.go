@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
-	"time"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/authz"
 	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/lockout"
 	"tincho.dev/rest-ws/middlewares"
 	"tincho.dev/rest-ws/models"
 	"tincho.dev/rest-ws/repositories"
@@ -17,10 +20,6 @@ import (
 	"tincho.dev/rest-ws/utils"
 )
 
-const (
-	EXPIRATION_TIME = time.Hour * 24
-)
-
 func SignUpHandler(s server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -37,13 +36,13 @@ func SignUpHandler(s server.Server) http.HandlerFunc {
 			return
 		}
 
-		_, err = repositories.GetUserByEmail(r.Context(), payload.Email)
+		err = s.Config().PasswordPolicy.Check(payload.Password)
 
-		if err == nil {
-			w.WriteHeader(http.StatusConflict)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
 
 			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Email already exists",
+				"error": err.Error(),
 			})
 
 			return
@@ -61,13 +60,33 @@ func SignUpHandler(s server.Server) http.HandlerFunc {
 			Password: string(hashedPassword),
 		}
 
+		// The unique constraint on the email rejects the address when it is
+		// taken, even by a concurrent sign up.
 		err = repositories.CreateUser(r.Context(), user)
 
+		if errors.Is(err, repositories.ErrConflict) {
+			w.WriteHeader(http.StatusConflict)
+
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Email already exists",
+			})
+
+			return
+		}
+
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
+		// The account already exists, so a failure here is only logged. The
+		// user can ask for a new link with /verify-email/resend.
+		err = sendVerificationEmail(r.Context(), s, user)
+
+		if err != nil {
+			log.Println("Error sending verification email: ", err)
+		}
+
 		response := dto.SignUpResponse{
 			Id:    user.Id,
 			Email: user.Email,
@@ -85,7 +104,7 @@ func FindAllUsersHandler(s server.Server) http.HandlerFunc {
 		users, err := repositories.FindAllUsers(r.Context())
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
@@ -93,8 +112,9 @@ func FindAllUsersHandler(s server.Server) http.HandlerFunc {
 
 		for _, user := range users {
 			response = append(response, dto.FindAllUsersResponse{
-				Id:    user.Id,
-				Email: user.Email,
+				Id:     user.Id,
+				Email:  user.Email,
+				Online: s.Hub().IsOnline(user.Id),
 			})
 		}
 
@@ -117,7 +137,7 @@ func ListUsersHandler(s server.Server) http.HandlerFunc {
 		users, err := repositories.ListUsers(r.Context(), offset, limit)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
@@ -125,8 +145,38 @@ func ListUsersHandler(s server.Server) http.HandlerFunc {
 
 		for _, user := range users {
 			response = append(response, dto.FindAllUsersResponse{
-				Id:    user.Id,
-				Email: user.Email,
+				Id:     user.Id,
+				Email:  user.Email,
+				Online: s.Hub().IsOnline(user.Id),
+			})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func OnlineUsersHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		userIds := s.Hub().OnlineUsers()
+		sort.Slice(userIds, func(i, j int) bool { return userIds[i] < userIds[j] })
+
+		response := make([]dto.FindAllUsersResponse, 0, len(userIds))
+
+		for _, userId := range userIds {
+			user, err := repositories.FindUserById(r.Context(), userId)
+
+			if err != nil {
+				// The user may have been deleted while still connected.
+				continue
+			}
+
+			response = append(response, dto.FindAllUsersResponse{
+				Id:     user.Id,
+				Email:  user.Email,
+				Online: true,
 			})
 		}
 
@@ -150,7 +200,7 @@ func FindOneUserHandler(s server.Server) http.HandlerFunc {
 		user, err := repositories.FindUserById(r.Context(), userId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
@@ -180,42 +230,84 @@ func SignInHandler(s server.Server) http.HandlerFunc {
 			return
 		}
 
-		user, err := repositories.GetUserByEmail(r.Context(), payload.Email)
+		account := lockout.Account(payload.Email)
+		ip := lockout.IP(utils.ClientIP(r))
 
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		attempt, ok := beginAttempt(w, r, account, ip)
+
+		if !ok {
 			return
 		}
 
-		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
+		user, err := repositories.GetUserByEmail(r.Context(), payload.Email)
+
+		if err == nil {
+			err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
+		}
 
 		if err != nil {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		claims := &models.AppClaims{
-			UserId: user.Id,
-			StandardClaims: jwt.StandardClaims{
-				ExpiresAt: time.Now().Add(EXPIRATION_TIME).Unix(),
-			},
+		err = attempt.Forget(r.Context())
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		signedToken, err := token.SignedString([]byte(s.Config().JWTSecret))
+		err = lockout.Reset(r.Context(), account)
 
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		response := &dto.SignInResponse{
-			Token: signedToken,
+		completeSignIn(w, r, s, user)
+	}
+}
+
+// completeSignIn answers a sign in that proved who the user is with their
+// tokens, or with a challenge to send to /signin/mfa with a code when they
+// have two-factor authentication enabled.
+func completeSignIn(w http.ResponseWriter, r *http.Request, s server.Server, user *models.User) {
+	mfa, err := repositories.FindMFA(r.Context(), user.Id)
+
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err == nil && mfa.ConfirmedAt != nil {
+		challenge, err := issueMFAChallenge(s, user)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
+		json.NewEncoder(w).Encode(challenge)
+		return
+	}
+
+	sessionId, err := startSession(r, user.Id)
+
+	if err != nil {
+		writeRepositoryError(w, err, "")
+		return
+	}
+
+	response, err := issueTokens(r.Context(), s, user, sessionId)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
 }
 
 func MeHandler(s server.Server) http.HandlerFunc {
@@ -227,14 +319,16 @@ func MeHandler(s server.Server) http.HandlerFunc {
 		user, err := repositories.FindUserById(r.Context(), claims.UserId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
 		response := &dto.GetMeDataResponse{
-			Id:    user.Id,
-			Email: user.Email,
-			Posts: user.Posts,
+			Id:            user.Id,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerifiedAt != nil,
+			Role:          user.Role,
+			Posts:         user.Posts,
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -242,12 +336,39 @@ func MeHandler(s server.Server) http.HandlerFunc {
 	}
 }
 
+// targetUserId reads the {id} route parameter and checks the action is
+// allowed on that user. It writes the error response when it fails.
+func targetUserId(w http.ResponseWriter, r *http.Request, claims *models.AppClaims, action authz.Action) (int64, bool) {
+	userId, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid request",
+		})
+		return 0, false
+	}
+
+	if !authz.Can(r.Context(), claims, action, authz.User(userId)) {
+		writeRepositoryError(w, repositories.ErrForbidden, "")
+		return 0, false
+	}
+
+	return userId, true
+}
+
 func UpdateUserHandler(s server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
 
+		userId, ok := targetUserId(w, r, claims, authz.UpdateAction)
+
+		if !ok {
+			return
+		}
+
 		payload, err := utils.Validate[dto.UpdateUserRequest](r)
 
 		if err != nil {
@@ -258,22 +379,36 @@ func UpdateUserHandler(s server.Server) http.HandlerFunc {
 			return
 		}
 
-		user, err := repositories.FindUserById(r.Context(), claims.UserId)
+		user, err := repositories.FindUserById(r.Context(), userId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
+		emailChanged := user.Email != payload.Email
+
 		user.Email = payload.Email
 
+		if emailChanged {
+			user.EmailVerifiedAt = nil
+		}
+
 		err = repositories.UpdateOneUser(r.Context(), user)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
+		if emailChanged {
+			err = sendVerificationEmail(r.Context(), s, user)
+
+			if err != nil {
+				log.Println("Error sending verification email: ", err)
+			}
+		}
+
 		response := &dto.UpdateUserResponse{
 			Id:    user.Id,
 			Email: user.Email,
@@ -290,13 +425,68 @@ func DeleteUserHandler(s server.Server) http.HandlerFunc {
 
 		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
 
-		err := repositories.DeleteOneUser(r.Context(), claims.UserId)
+		userId, ok := targetUserId(w, r, claims, authz.DeleteAction)
+
+		if !ok {
+			return
+		}
+
+		err := repositories.DeleteOneUser(r.Context(), userId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeRepositoryError(w, err, "")
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+func UpdateUserRoleHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		userId, ok := targetUserId(w, r, claims, authz.UpdateRoleAction)
+
+		if !ok {
+			return
+		}
+
+		payload, err := utils.Validate[dto.UpdateUserRoleRequest](r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), userId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		user.Role = payload.Role
+
+		err = repositories.UpdateOneUser(r.Context(), user)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		response := &dto.UpdateUserRoleResponse{
+			Id:    user.Id,
+			Email: user.Email,
+			Role:  user.Role,
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
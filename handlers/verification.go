@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"tincho.dev/rest-ws/mailer"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+const (
+	EMAIL_VERIFICATION_EXPIRATION_TIME = 24 * time.Hour
+)
+
+// sendVerificationEmail creates a single use verification token for the user
+// and emails them a link to confirm their address.
+func sendVerificationEmail(ctx context.Context, s server.Server, user *models.User) error {
+	token, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = repositories.CreateEmailVerification(ctx, &models.EmailVerification{
+		UserID:    user.Id,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(EMAIL_VERIFICATION_EXPIRATION_TIME),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sendMail(&mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Follow this link within the next 24 hours to verify your email:\n\n%s/verify-email?token=%s",
+			s.Config().AppURL, url.QueryEscape(token),
+		),
+	})
+
+	return nil
+}
+
+func VerifyEmailHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		token := r.URL.Query().Get("token")
+
+		verification, err := repositories.FindEmailVerificationByHash(r.Context(), utils.HashToken(token))
+
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if token == "" || err != nil || verification.UsedAt != nil || time.Now().After(verification.ExpiresAt) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid or expired token",
+			})
+			return
+		}
+
+		used, err := repositories.UseEmailVerification(r.Context(), verification.ID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if !used {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid or expired token",
+			})
+			return
+		}
+
+		user, err := repositories.FindUserById(r.Context(), verification.UserID)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		// The user changed their email after the link was sent.
+		if user.Email != verification.Email {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Invalid or expired token",
+			})
+			return
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+
+		err = repositories.UpdateOneUser(r.Context(), user)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Email verified",
+		})
+	}
+}
+
+func ResendVerificationEmailHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		claims := r.Context().Value(middlewares.ClaimsKey).(*models.AppClaims)
+
+		user, err := repositories.FindUserById(r.Context(), claims.UserId)
+
+		if err != nil {
+			writeRepositoryError(w, err, "")
+			return
+		}
+
+		if user.EmailVerifiedAt != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Email already verified",
+			})
+			return
+		}
+
+		err = sendVerificationEmail(r.Context(), s, user)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Verification email sent",
+		})
+	}
+}
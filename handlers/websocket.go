@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/websocket"
+)
+
+func WebSocketHandler(s server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middlewares.ParseToken(r.Context(), s, websocket.TokenFromRequest(r))
+
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		client := s.Hub().HandleWebSocket(w, r, claims)
+
+		if client == nil {
+			return
+		}
+
+		// Deliver what the user missed while they were offline.
+		messages, err := repositories.FindUnreadMessages(r.Context(), claims.UserId)
+
+		if err != nil {
+			log.Println("Error finding unread messages: ", err)
+			return
+		}
+
+		if len(messages) > 0 {
+			s.Hub().Send(client, models.Event{
+				Type:    models.MessageUnreadEvent,
+				Payload: messages,
+			})
+		}
+	}
+}
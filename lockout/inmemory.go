@@ -0,0 +1,85 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type attempts struct {
+	failures int
+	last     time.Time
+}
+
+// InMemory counts failures in the process. They are lost on restart and
+// aren't shared between instances, so it is meant for development and tests.
+type InMemory struct {
+	mutex    sync.Mutex
+	attempts map[string]*attempts
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{
+		attempts: make(map[string]*attempts),
+	}
+}
+
+func (m *InMemory) RecordLoginAttempt(ctx context.Context, key string, at time.Time, windowStart time.Time) (int, time.Time, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Failures out of the window don't count anymore, there is no need to
+	// remember them.
+	for k, a := range m.attempts {
+		if a.last.Before(windowStart) {
+			delete(m.attempts, k)
+		}
+	}
+
+	a, ok := m.attempts[key]
+
+	if !ok {
+		a = &attempts{}
+		m.attempts[key] = a
+	}
+
+	failures, last := a.failures, a.last
+
+	a.failures++
+	a.last = at
+
+	return failures, last, nil
+}
+
+func (m *InMemory) ForgetLoginAttempt(ctx context.Context, key string, at time.Time, previous time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, ok := m.attempts[key]
+
+	if !ok {
+		return nil
+	}
+
+	a.failures--
+
+	// A later attempt is the last one already.
+	if a.last.Equal(at) {
+		a.last = previous
+	}
+
+	if a.failures <= 0 {
+		delete(m.attempts, key)
+	}
+
+	return nil
+}
+
+func (m *InMemory) ResetLoginFailures(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.attempts, key)
+
+	return nil
+}
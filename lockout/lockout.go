@@ -0,0 +1,144 @@
+// Package lockout slows down password guessing by counting failed sign ins
+// per account and per client IP, and rejecting new attempts for a time that
+// doubles with every failure past a threshold.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Store interface {
+	// RecordLoginAttempt counts an attempt for the key as failed. It returns
+	// how many failures there were since windowStart before it, and when the
+	// last of them happened. Older failures are forgotten.
+	RecordLoginAttempt(ctx context.Context, key string, at time.Time, windowStart time.Time) (int, time.Time, error)
+	// ForgetLoginAttempt takes back the attempt recorded at the given time,
+	// which followed the failure at previous.
+	ForgetLoginAttempt(ctx context.Context, key string, at time.Time, previous time.Time) error
+	ResetLoginFailures(ctx context.Context, key string) error
+}
+
+// Policy sets when a key gets locked and for how long.
+type Policy struct {
+	// Threshold is the number of failures allowed before locking.
+	Threshold int
+	// BaseDelay is the lockout after Threshold failures, doubled by every
+	// further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered.
+	Window time.Duration
+}
+
+var (
+	AccountPolicy = Policy{Threshold: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Window: 24 * time.Hour}
+	// IPPolicy is more lenient, since several users can share an address.
+	IPPolicy = Policy{Threshold: 20, BaseDelay: 30 * time.Second, MaxDelay: time.Hour, Window: 24 * time.Hour}
+)
+
+// Delay returns how long to lock a key after the given number of failures.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+type Key struct {
+	Name   string
+	Policy Policy
+}
+
+// Account is the key of the sign ins with an email, whether it is registered
+// or not.
+func Account(email string) Key {
+	return Key{Name: "account:" + strings.ToLower(email), Policy: AccountPolicy}
+}
+
+// MFA is the key of the codes sent to finish signing in as a user.
+func MFA(userId int64) Key {
+	return Key{Name: fmt.Sprintf("mfa:%d", userId), Policy: AccountPolicy}
+}
+
+func IP(ip string) Key {
+	return Key{Name: "ip:" + ip, Policy: IPPolicy}
+}
+
+var implementation Store
+
+func SetStore(store Store) {
+	implementation = store
+}
+
+// Attempt is a sign in attempt counted as failed before knowing how it went.
+type Attempt struct {
+	at       time.Time
+	keys     []Key
+	previous []time.Time
+}
+
+// Begin counts an attempt for every key as failed, so parallel attempts
+// can't all get past the lockout, and returns how long until any of the keys
+// could have been tried, or zero if none was locked.
+func Begin(ctx context.Context, keys ...Key) (*Attempt, time.Duration, error) {
+	attempt := &Attempt{at: time.Now()}
+	var retryAfter time.Duration
+
+	for _, key := range keys {
+		failures, last, err := implementation.RecordLoginAttempt(ctx, key.Name, attempt.at, attempt.at.Add(-key.Policy.Window))
+
+		if err != nil {
+			attempt.Forget(ctx)
+			return nil, 0, err
+		}
+
+		attempt.keys = append(attempt.keys, key)
+		attempt.previous = append(attempt.previous, last)
+
+		if wait := last.Add(key.Policy.Delay(failures)).Sub(attempt.at); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return attempt, retryAfter, nil
+}
+
+// Forget takes the attempt back, for the ones that succeeded or were
+// rejected before being checked.
+func (a *Attempt) Forget(ctx context.Context) error {
+	for i, key := range a.keys {
+		err := implementation.ForgetLoginAttempt(ctx, key.Name, a.at, a.previous[i])
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Reset(ctx context.Context, keys ...Key) error {
+	for _, key := range keys {
+		err := implementation.ResetLoginFailures(ctx, key.Name)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Log writes messages to a writer, such as a file or stdout, instead of
+// delivering them. It is meant for local development and tests.
+type Log struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewLog(writer io.Writer) *Log {
+	return &Log{writer: writer}
+}
+
+func (l *Log) Send(ctx context.Context, m *Message) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, err := fmt.Fprintf(l.writer, "To: %s\nSubject: %s\n\n%s\n---\n", m.To, m.Subject, m.Body)
+
+	return err
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+var implementation Mailer
+
+func SetMailer(mailer Mailer) {
+	implementation = mailer
+}
+
+func Send(ctx context.Context, m *Message) error {
+	return implementation.Send(ctx, m)
+}
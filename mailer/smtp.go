@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type SMTP struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTP(host string, port string, username string, password string, from string) *SMTP {
+	return &SMTP{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, m *Message) error {
+	var auth smtp.Auth
+
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	headers := []string{
+		"From: " + s.from,
+		"To: " + m.To,
+		"Subject: " + m.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(m.Body, "\n", "\r\n")
+
+	err := smtp.SendMail(net.JoinHostPort(s.host, s.port), auth, s.from, []string{m.To}, []byte(body))
+
+	if err != nil {
+		return fmt.Errorf("sending mail to %s: %w", m.To, err)
+	}
+
+	return nil
+}
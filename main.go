@@ -4,12 +4,16 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"tincho.dev/rest-ws/handlers"
 	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
 	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
 )
 
 func main() {
@@ -21,12 +25,73 @@ func main() {
 
 	PORT := os.Getenv("PORT")
 	JWT_SECRET := os.Getenv("JWT_SECRET")
+	JWT_KEY_FILES := os.Getenv("JWT_KEY_FILES")
+	STORAGE := os.Getenv("STORAGE")
 	DATABASE_URL := os.Getenv("DATABASE_URL")
+	PUBSUB := os.Getenv("PUBSUB")
+	REVOCATION_STORE := os.Getenv("REVOCATION_STORE")
+	LOCKOUT_STORE := os.Getenv("LOCKOUT_STORE")
+	APP_URL := os.Getenv("APP_URL")
+	MAILER := os.Getenv("MAILER")
+	MAIL_LOG_FILE := os.Getenv("MAIL_LOG_FILE")
+	MAIL_FROM := os.Getenv("MAIL_FROM")
+	SMTP_HOST := os.Getenv("SMTP_HOST")
+	SMTP_PORT := os.Getenv("SMTP_PORT")
+	SMTP_USERNAME := os.Getenv("SMTP_USERNAME")
+	SMTP_PASSWORD := os.Getenv("SMTP_PASSWORD")
+	REQUIRE_VERIFIED_EMAIL := os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true"
+	PASSWORD_REQUIRE_MIXED_CASE := os.Getenv("PASSWORD_REQUIRE_MIXED_CASE") == "true"
+	PASSWORD_REQUIRE_DIGIT := os.Getenv("PASSWORD_REQUIRE_DIGIT") == "true"
+	PASSWORD_REQUIRE_SYMBOL := os.Getenv("PASSWORD_REQUIRE_SYMBOL") == "true"
+	OIDC_ISSUER_URL := os.Getenv("OIDC_ISSUER_URL")
+	OIDC_CLIENT_ID := os.Getenv("OIDC_CLIENT_ID")
+	OIDC_CLIENT_SECRET := os.Getenv("OIDC_CLIENT_SECRET")
+	OIDC_REDIRECT_URL := os.Getenv("OIDC_REDIRECT_URL")
+	AUTO_MIGRATE := os.Getenv("AUTO_MIGRATE") == "true"
+	PASSWORD_MIN_LENGTH := 0
+
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		PASSWORD_MIN_LENGTH, err = strconv.Atoi(value)
+
+		if err != nil {
+			log.Fatal("Invalid PASSWORD_MIN_LENGTH: ", err)
+		}
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(DATABASE_URL, os.Args[2:])
+		return
+	}
 
 	s, err := server.NewServer(context.Background(), &server.Config{
-		Port:        PORT,
-		JWTSecret:   JWT_SECRET,
-		DatabaseURL: DATABASE_URL,
+		Port:                 PORT,
+		JWTSecret:            JWT_SECRET,
+		JWTKeyFiles:          splitList(JWT_KEY_FILES),
+		Storage:              STORAGE,
+		DatabaseURL:          DATABASE_URL,
+		AutoMigrate:          AUTO_MIGRATE,
+		PubSub:               PUBSUB,
+		Revocation:           REVOCATION_STORE,
+		Lockout:              LOCKOUT_STORE,
+		AppURL:               APP_URL,
+		Mailer:               MAILER,
+		MailLogFile:          MAIL_LOG_FILE,
+		MailFrom:             MAIL_FROM,
+		SMTPHost:             SMTP_HOST,
+		SMTPPort:             SMTP_PORT,
+		SMTPUsername:         SMTP_USERNAME,
+		SMTPPassword:         SMTP_PASSWORD,
+		RequireVerifiedEmail: REQUIRE_VERIFIED_EMAIL,
+		PasswordPolicy: utils.PasswordPolicy{
+			MinLength:        PASSWORD_MIN_LENGTH,
+			RequireMixedCase: PASSWORD_REQUIRE_MIXED_CASE,
+			RequireDigit:     PASSWORD_REQUIRE_DIGIT,
+			RequireSymbol:    PASSWORD_REQUIRE_SYMBOL,
+		},
+		OIDCIssuerURL:    OIDC_ISSUER_URL,
+		OIDCClientID:     OIDC_CLIENT_ID,
+		OIDCClientSecret: OIDC_CLIENT_SECRET,
+		OIDCRedirectURL:  OIDC_REDIRECT_URL,
 	})
 
 	if err != nil {
@@ -36,6 +101,19 @@ func main() {
 	s.Start(binder)
 }
 
+// splitList parses a comma separated environment variable.
+func splitList(value string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func binder(s server.Server, r *mux.Router) {
 	r.Use(middlewares.AuthMiddleware(s))
 
@@ -44,14 +122,41 @@ func binder(s server.Server, r *mux.Router) {
 	// Auth routes
 	r.HandleFunc("/signup", handlers.SignUpHandler(s)).Methods("POST")
 	r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+	r.HandleFunc("/signin/mfa", handlers.MFASignInHandler(s)).Methods("POST")
+	r.HandleFunc("/auth/oidc/login", handlers.OIDCLoginHandler(s)).Methods("GET")
+	r.HandleFunc("/auth/oidc/callback", handlers.OIDCCallbackHandler(s)).Methods("GET")
+	r.HandleFunc("/token/refresh", handlers.RefreshTokenHandler(s)).Methods("POST")
+	r.HandleFunc("/signout", handlers.SignOutHandler(s)).Methods("POST")
+	r.HandleFunc("/.well-known/jwks.json", handlers.JWKSHandler(s)).Methods("GET")
+	r.HandleFunc("/password/forgot", handlers.ForgotPasswordHandler(s)).Methods("POST")
+	r.HandleFunc("/password/reset", handlers.ResetPasswordHandler(s)).Methods("POST")
+	r.HandleFunc("/verify-email", handlers.VerifyEmailHandler(s)).Methods("GET")
+	r.HandleFunc("/verify-email/resend", handlers.ResendVerificationEmailHandler(s)).Methods("POST")
 
 	// User routes
 	r.HandleFunc("/users", handlers.FindAllUsersHandler(s)).Methods("GET")
 	r.HandleFunc("/users/list", handlers.ListUsersHandler(s)).Methods("GET")
 	r.HandleFunc("/users/me", handlers.MeHandler(s)).Methods("GET")
+	r.HandleFunc("/users/online", handlers.OnlineUsersHandler(s)).Methods("GET")
+	r.HandleFunc("/users/me/password", handlers.ChangePasswordHandler(s)).Methods("PUT")
+	r.HandleFunc("/users/me/mfa", handlers.EnrollMFAHandler(s)).Methods("POST")
+	r.HandleFunc("/users/me/mfa/confirm", handlers.ConfirmMFAHandler(s)).Methods("POST")
+	r.HandleFunc("/users/me/mfa", handlers.DisableMFAHandler(s)).Methods("DELETE")
+	r.HandleFunc("/users/me/api-keys", handlers.CreateAPIKeyHandler(s)).Methods("POST")
+	r.HandleFunc("/users/me/api-keys", handlers.ListAPIKeysHandler(s)).Methods("GET")
+	r.HandleFunc("/users/me/api-keys/{id}", handlers.DeleteAPIKeyHandler(s)).Methods("DELETE")
+	r.HandleFunc("/users/me/sessions", handlers.ListSessionsHandler(s)).Methods("GET")
+	r.HandleFunc("/users/me/sessions/revoke-all", handlers.RevokeAllSessionsHandler(s)).Methods("POST")
+	r.HandleFunc("/users/me/sessions/{id}", handlers.DeleteSessionHandler(s)).Methods("DELETE")
 	r.HandleFunc("/users/{id}", handlers.FindOneUserHandler(s)).Methods("GET")
 	r.HandleFunc("/users/{id}", handlers.UpdateUserHandler(s)).Methods("PUT")
 	r.HandleFunc("/users/{id}", handlers.DeleteUserHandler(s)).Methods("DELETE")
+	r.Handle("/users/{id}/role", middlewares.RequireRole(models.AdminRole)(handlers.UpdateUserRoleHandler(s))).Methods("PUT")
+	r.Handle("/users/{id}/unlock", middlewares.RequireRole(models.AdminRole)(handlers.UnlockUserHandler(s))).Methods("POST")
+
+	// Message routes
+	r.HandleFunc("/users/{id}/messages", handlers.SendMessageHandler(s)).Methods("POST")
+	r.HandleFunc("/users/{id}/messages", handlers.ListMessagesHandler(s)).Methods("GET")
 
 	// Post routes
 	r.HandleFunc("/posts", handlers.CreatePostHandler(s)).Methods("POST")
@@ -59,4 +164,10 @@ func binder(s server.Server, r *mux.Router) {
 	r.HandleFunc("/posts", handlers.FindAllPostsHandler(s)).Methods("GET")
 	r.HandleFunc("/posts/{id}", handlers.UpdateOnePostHandler(s)).Methods("PUT")
 	r.HandleFunc("/posts/{id}", handlers.DeleteOnePostHandler(s)).Methods("DELETE")
+
+	// WebSocket routes
+	r.HandleFunc("/ws", handlers.WebSocketHandler(s))
+
+	// Server-Sent Events routes
+	r.HandleFunc("/events", handlers.EventsHandler(s)).Methods("GET")
 }
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(key.UserID) {
+		return ErrUnknownUser
+	}
+
+	key.ID = m.id("api_keys")
+	key.CreatedAt = time.Now()
+	m.apiKeys = append(m.apiKeys, copyAPIKey(key))
+
+	return nil
+}
+
+func (m *Memory) FindAPIKeyById(ctx context.Context, id int64) (*models.APIKey, error) {
+	return m.findAPIKey(func(k *models.APIKey) bool { return k.ID == id })
+}
+
+func (m *Memory) FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return m.findAPIKey(func(k *models.APIKey) bool { return k.KeyHash == hash })
+}
+
+func (m *Memory) findAPIKey(match func(k *models.APIKey) bool) (*models.APIKey, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for i := range m.apiKeys {
+		if match(&m.apiKeys[i]) {
+			k := copyAPIKey(&m.apiKeys[i])
+
+			return &k, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) ListAPIKeys(ctx context.Context, userId int64) ([]models.APIKey, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	keys := []models.APIKey{}
+
+	for i := range m.apiKeys {
+		if m.apiKeys[i].UserID == userId {
+			keys = append(keys, copyAPIKey(&m.apiKeys[i]))
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (m *Memory) TouchAPIKey(ctx context.Context, id int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.apiKeys {
+		if m.apiKeys[i].ID == id {
+			now := time.Now()
+			m.apiKeys[i].LastUsedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteAPIKey(ctx context.Context, id int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.apiKeys = filter(m.apiKeys, func(k *models.APIKey) bool { return k.ID == id })
+
+	return nil
+}
+
+func (m *Memory) DeleteUserAPIKeys(ctx context.Context, userId int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.apiKeys = filter(m.apiKeys, func(k *models.APIKey) bool { return k.UserID == userId })
+
+	return nil
+}
+
+// copyAPIKey keeps the scopes from being shared with the caller.
+func copyAPIKey(key *models.APIKey) models.APIKey {
+	k := *key
+	k.Scopes = append([]string{}, key.Scopes...)
+
+	return k
+}
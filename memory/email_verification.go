@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateEmailVerification(ctx context.Context, verification *models.EmailVerification) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(verification.UserID) {
+		return ErrUnknownUser
+	}
+
+	verification.ID = m.id("email_verifications")
+	m.emailVerifications = append(m.emailVerifications, *verification)
+
+	return nil
+}
+
+func (m *Memory) FindEmailVerificationByHash(ctx context.Context, hash string) (*models.EmailVerification, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, verification := range m.emailVerifications {
+		if verification.TokenHash == hash {
+			return &verification, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) UseEmailVerification(ctx context.Context, id int64) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.emailVerifications {
+		verification := &m.emailVerifications[i]
+
+		if verification.ID == id && verification.UsedAt == nil {
+			now := time.Now()
+			verification.UsedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(identity.UserID) {
+		return ErrUnknownUser
+	}
+
+	for _, i := range m.externalIdentities {
+		if i.Issuer == identity.Issuer && i.Subject == identity.Subject {
+			return ErrDuplicateIdentity
+		}
+	}
+
+	identity.ID = m.id("external_identities")
+	identity.CreatedAt = time.Now()
+	m.externalIdentities = append(m.externalIdentities, *identity)
+
+	return nil
+}
+
+func (m *Memory) FindExternalIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, i := range m.externalIdentities {
+		if i.Issuer == issuer && i.Subject == subject {
+			return &i, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
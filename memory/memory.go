@@ -0,0 +1,85 @@
+// Package memory implements the repositories in the process memory, so the
+// server can run without a database. Everything is lost on restart.
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+var (
+	// The duplicates are conflicts, like the unique constraints of the
+	// database, and a missing user isn't found, like its foreign keys.
+	ErrDuplicateEmail    = fmt.Errorf("%w: a user with this email already exists", repositories.ErrConflict)
+	ErrDuplicateIdentity = fmt.Errorf("%w: the identity is already linked to a user", repositories.ErrConflict)
+	ErrUnknownUser       = fmt.Errorf("%w: the user doesn't exist", repositories.ErrNotFound)
+)
+
+// Memory keeps every table behind a single lock, so deleting a user can
+// remove everything they own at once, like the foreign keys of the database.
+type Memory struct {
+	mutex   sync.RWMutex
+	nextIds map[string]int64
+
+	users              []models.User
+	posts              []models.Post
+	messages           []models.Message
+	refreshTokens      []models.RefreshToken
+	sessions           []models.Session
+	passwordResets     []models.PasswordReset
+	emailVerifications []models.EmailVerification
+	mfa                []models.MFA
+	recoveryCodes      []recoveryCode
+	apiKeys            []models.APIKey
+	externalIdentities []models.ExternalIdentity
+}
+
+type recoveryCode struct {
+	userId int64
+	hash   string
+	usedAt *time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{nextIds: make(map[string]int64)}
+}
+
+// id returns the next id of the table, like a SERIAL column.
+func (m *Memory) id(table string) int64 {
+	m.nextIds[table]++
+
+	return m.nextIds[table]
+}
+
+// timestamp formats the time the way the database driver does for the
+// columns scanned into strings.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func (m *Memory) userExists(id int64) bool {
+	for _, u := range m.users {
+		if u.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filter keeps the items of the slice that don't match.
+func filter[T any](items []T, match func(item *T) bool) []T {
+	kept := items[:0]
+
+	for i := range items {
+		if !match(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+func (m *Memory) CreateMessage(ctx context.Context, message *models.Message) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(message.SenderID) || !m.userExists(message.RecipientID) {
+		return ErrUnknownUser
+	}
+
+	message.ID = m.id("messages")
+	message.CreatedAt = timestamp(time.Now())
+	m.messages = append(m.messages, *message)
+
+	return nil
+}
+
+// ListMessages returns the conversation newest first.
+func (m *Memory) ListMessages(ctx context.Context, userId int64, otherUserId int64, offset int64, limit int64) ([]models.Message, error) {
+	if offset < 0 || limit < 0 {
+		return []models.Message{}, nil
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	messages := []models.Message{}
+	skip := limit * offset
+
+	for i := len(m.messages) - 1; i >= 0 && int64(len(messages)) < limit; i-- {
+		msg := m.messages[i]
+
+		if !(msg.SenderID == userId && msg.RecipientID == otherUserId) && !(msg.SenderID == otherUserId && msg.RecipientID == userId) {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+func (m *Memory) FindUnreadMessages(ctx context.Context, recipientId int64) ([]models.Message, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	messages := []models.Message{}
+
+	for _, msg := range m.messages {
+		if msg.RecipientID == recipientId && msg.ReadAt == nil {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+func (m *Memory) MarkMessagesAsRead(ctx context.Context, recipientId int64, senderId int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	readAt := timestamp(time.Now())
+
+	for i := range m.messages {
+		msg := &m.messages[i]
+
+		if msg.RecipientID == recipientId && msg.SenderID == senderId && msg.ReadAt == nil {
+			msg.ReadAt = &readAt
+		}
+	}
+
+	return nil
+}
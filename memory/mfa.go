@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) SaveMFASecret(ctx context.Context, userId int64, secret string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(userId) {
+		return ErrUnknownUser
+	}
+
+	m.mfa = filter(m.mfa, func(f *models.MFA) bool { return f.UserID == userId })
+	m.mfa = append(m.mfa, models.MFA{UserID: userId, Secret: secret})
+
+	return nil
+}
+
+func (m *Memory) FindMFA(ctx context.Context, userId int64) (*models.MFA, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, f := range m.mfa {
+		if f.UserID == userId {
+			return &f, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) ConfirmMFA(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.mfa {
+		if m.mfa[i].UserID == userId {
+			now := time.Now()
+			m.mfa[i].ConfirmedAt = &now
+		}
+	}
+
+	m.recoveryCodes = filter(m.recoveryCodes, func(c *recoveryCode) bool { return c.userId == userId })
+
+	for _, hash := range recoveryCodeHashes {
+		m.recoveryCodes = append(m.recoveryCodes, recoveryCode{userId: userId, hash: hash})
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteMFA(ctx context.Context, userId int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.recoveryCodes = filter(m.recoveryCodes, func(c *recoveryCode) bool { return c.userId == userId })
+	m.mfa = filter(m.mfa, func(f *models.MFA) bool { return f.UserID == userId })
+
+	return nil
+}
+
+func (m *Memory) UseMFAStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.mfa {
+		if m.mfa[i].UserID == userId && m.mfa[i].LastStep < step {
+			m.mfa[i].LastStep = step
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *Memory) UseRecoveryCode(ctx context.Context, userId int64, hash string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.recoveryCodes {
+		c := &m.recoveryCodes[i]
+
+		if c.userId == userId && c.hash == hash && c.usedAt == nil {
+			now := time.Now()
+			c.usedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(reset.UserID) {
+		return ErrUnknownUser
+	}
+
+	reset.ID = m.id("password_resets")
+	m.passwordResets = append(m.passwordResets, *reset)
+
+	return nil
+}
+
+func (m *Memory) FindPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordReset, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, reset := range m.passwordResets {
+		if reset.TokenHash == hash {
+			return &reset, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) UsePasswordReset(ctx context.Context, id int64) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.passwordResets {
+		reset := &m.passwordResets[i]
+
+		if reset.ID == id && reset.UsedAt == nil {
+			now := time.Now()
+			reset.UsedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
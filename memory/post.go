@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreatePost(ctx context.Context, post *models.Post) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(post.UserID) {
+		return ErrUnknownUser
+	}
+
+	now := timestamp(time.Now())
+	post.ID = m.id("posts")
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	m.posts = append(m.posts, *post)
+
+	return nil
+}
+
+func (m *Memory) FindAllPosts(ctx context.Context) ([]models.Post, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]models.Post{}, m.posts...), nil
+}
+
+func (m *Memory) FindPostById(ctx context.Context, id int64) (*models.Post, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, post := range m.posts {
+		if post.ID == id {
+			return &post, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) findPostsByUserId(userId int64) []models.Post {
+	posts := []models.Post{}
+
+	for _, post := range m.posts {
+		if post.UserID == userId {
+			posts = append(posts, post)
+		}
+	}
+
+	return posts
+}
+
+func (m *Memory) UpdatePost(ctx context.Context, post *models.Post) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.posts {
+		if m.posts[i].ID == post.ID {
+			m.posts[i].Title = post.Title
+			m.posts[i].Content = post.Content
+			m.posts[i].UpdatedAt = timestamp(time.Now())
+
+			return nil
+		}
+	}
+
+	return repositories.ErrNotFound
+}
+
+func (m *Memory) DeletePost(ctx context.Context, id int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	count := len(m.posts)
+	m.posts = filter(m.posts, func(p *models.Post) bool { return p.ID == id })
+
+	if len(m.posts) == count {
+		return repositories.ErrNotFound
+	}
+
+	return nil
+}
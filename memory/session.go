@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateSession(ctx context.Context, session *models.Session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(session.UserID) {
+		return ErrUnknownUser
+	}
+
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	m.sessions = append(m.sessions, *session)
+
+	return nil
+}
+
+func (m *Memory) FindSessionById(ctx context.Context, id string) (*models.Session, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, s := range m.sessions {
+		if s.ID == id {
+			return &s, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) ListActiveSessions(ctx context.Context, userId int64) ([]models.Session, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	sessions := []models.Session{}
+	now := time.Now()
+
+	for _, s := range m.sessions {
+		if s.UserID == userId && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			sessions = append(sessions, s)
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (m *Memory) TouchSession(ctx context.Context, id string) error {
+	return m.updateSession(id, func(s *models.Session) {
+		s.LastSeenAt = time.Now()
+	})
+}
+
+func (m *Memory) ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	return m.updateSession(id, func(s *models.Session) {
+		s.LastSeenAt = time.Now()
+		s.ExpiresAt = expiresAt
+	})
+}
+
+func (m *Memory) RevokeSession(ctx context.Context, id string) error {
+	return m.updateSession(id, revokeSession)
+}
+
+func (m *Memory) RevokeUserSessions(ctx context.Context, userId int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.sessions {
+		if m.sessions[i].UserID == userId {
+			revokeSession(&m.sessions[i])
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) updateSession(id string, update func(s *models.Session)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.sessions {
+		if m.sessions[i].ID == id {
+			update(&m.sessions[i])
+		}
+	}
+
+	return nil
+}
+
+func revokeSession(s *models.Session) {
+	if s.RevokedAt == nil {
+		now := time.Now()
+		s.RevokedAt = &now
+	}
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(token.UserID) {
+		return ErrUnknownUser
+	}
+
+	token.ID = m.id("refresh_tokens")
+	m.refreshTokens = append(m.refreshTokens, *token)
+
+	return nil
+}
+
+func (m *Memory) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, t := range m.refreshTokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) RotateRefreshToken(ctx context.Context, id int64) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.refreshTokens {
+		t := &m.refreshTokens[i]
+
+		if t.ID == id && t.RotatedAt == nil && t.RevokedAt == nil {
+			now := time.Now()
+			t.RotatedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (m *Memory) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	return m.revokeRefreshTokens(func(t *models.RefreshToken) bool { return t.FamilyID == familyId })
+}
+
+func (m *Memory) RevokeUserRefreshTokens(ctx context.Context, userId int64) error {
+	return m.revokeRefreshTokens(func(t *models.RefreshToken) bool { return t.UserID == userId })
+}
+
+func (m *Memory) revokeRefreshTokens(match func(t *models.RefreshToken) bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+
+	for i := range m.refreshTokens {
+		t := &m.refreshTokens[i]
+
+		if match(t) && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
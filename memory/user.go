@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func (m *Memory) CreateUser(ctx context.Context, user *models.User) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.emailTaken(user.Email, 0) {
+		return ErrDuplicateEmail
+	}
+
+	if user.Role == "" {
+		user.Role = models.UserRole
+	}
+
+	user.Id = m.id("users")
+	m.users = append(m.users, copyUser(user))
+
+	return nil
+}
+
+func (m *Memory) ListUsers(ctx context.Context, offset int64, limit int64) ([]models.User, error) {
+	if offset < 0 || limit < 0 {
+		return []models.User{}, nil
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	users := []models.User{}
+
+	// Like the Postgres query, offset counts pages of limit users.
+	for i := limit * offset; i < int64(len(m.users)) && int64(len(users)) < limit; i++ {
+		users = append(users, copyUser(&m.users[i]))
+	}
+
+	return users, nil
+}
+
+func (m *Memory) FindAllUsers(ctx context.Context) ([]models.User, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	users := []models.User{}
+
+	for i := range m.users {
+		users = append(users, copyUser(&m.users[i]))
+	}
+
+	return users, nil
+}
+
+func (m *Memory) FindUserById(ctx context.Context, id int64) (*models.User, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for i := range m.users {
+		if m.users[i].Id == id {
+			u := copyUser(&m.users[i])
+			u.Posts = m.findPostsByUserId(id)
+
+			return &u, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for i := range m.users {
+		if m.users[i].Email == email {
+			u := copyUser(&m.users[i])
+
+			return &u, nil
+		}
+	}
+
+	return nil, repositories.ErrNotFound
+}
+
+func (m *Memory) UpdateOneUser(ctx context.Context, user *models.User) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.emailTaken(user.Email, user.Id) {
+		return ErrDuplicateEmail
+	}
+
+	for i := range m.users {
+		if m.users[i].Id == user.Id {
+			m.users[i] = copyUser(user)
+
+			return nil
+		}
+	}
+
+	return repositories.ErrNotFound
+}
+
+// DeleteOneUser also deletes everything the user owns.
+func (m *Memory) DeleteOneUser(ctx context.Context, id int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.userExists(id) {
+		return repositories.ErrNotFound
+	}
+
+	m.users = filter(m.users, func(u *models.User) bool { return u.Id == id })
+	m.posts = filter(m.posts, func(p *models.Post) bool { return p.UserID == id })
+	m.messages = filter(m.messages, func(msg *models.Message) bool { return msg.SenderID == id || msg.RecipientID == id })
+	m.refreshTokens = filter(m.refreshTokens, func(t *models.RefreshToken) bool { return t.UserID == id })
+	m.sessions = filter(m.sessions, func(s *models.Session) bool { return s.UserID == id })
+	m.passwordResets = filter(m.passwordResets, func(r *models.PasswordReset) bool { return r.UserID == id })
+	m.emailVerifications = filter(m.emailVerifications, func(v *models.EmailVerification) bool { return v.UserID == id })
+	m.mfa = filter(m.mfa, func(f *models.MFA) bool { return f.UserID == id })
+	m.recoveryCodes = filter(m.recoveryCodes, func(c *recoveryCode) bool { return c.userId == id })
+	m.apiKeys = filter(m.apiKeys, func(k *models.APIKey) bool { return k.UserID == id })
+	m.externalIdentities = filter(m.externalIdentities, func(i *models.ExternalIdentity) bool { return i.UserID == id })
+
+	return nil
+}
+
+// emailTaken reports whether a user other than the given one has the email.
+func (m *Memory) emailTaken(email string, exceptId int64) bool {
+	for _, u := range m.users {
+		if u.Email == email && u.Id != exceptId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyUser stores and returns users without their posts, which are kept
+// apart, and without sharing the verification time with the caller.
+func copyUser(user *models.User) models.User {
+	u := *user
+	u.Posts = nil
+
+	if user.EmailVerifiedAt != nil {
+		verifiedAt := user.EmailVerifiedAt.UTC()
+		u.EmailVerifiedAt = &verifiedAt
+	}
+
+	return u
+}
@@ -2,12 +2,18 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/revocation"
 	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
 )
 
 type contextKey string
@@ -15,7 +21,7 @@ type contextKey string
 const ClaimsKey contextKey = "claims"
 
 var (
-	NO_AUTH_ROUTES = []string{"/", "/signup", "/signin", "/users"}
+	NO_AUTH_ROUTES = []string{"/", "/signup", "/signin", "/signin/mfa", "/auth/oidc/login", "/auth/oidc/callback", "/users", "/ws", "/events", "/token/refresh", "/.well-known/jwks.json", "/password/forgot", "/password/reset", "/verify-email"}
 )
 
 func shouldAuth(route string) bool {
@@ -28,6 +34,89 @@ func shouldAuth(route string) bool {
 	return true
 }
 
+var ErrInvalidToken = errors.New("invalid token")
+
+// ParseToken validates a signed token issued by SignInHandler, checks it
+// hasn't been revoked and returns its claims.
+func ParseToken(ctx context.Context, s server.Server, tokenString string) (*models.AppClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&models.AppClaims{},
+		s.Keys().Keyfunc,
+	)
+
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*models.AppClaims)
+
+	// MFA challenges and OIDC states are signed with the same keys, but
+	// aren't access tokens. Only those have no audience.
+	if !ok || claims.Audience != "" {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := revocation.IsRevoked(ctx, claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// ParseAPIKey finds the user an API key belongs to and returns claims for
+// them with the key's scopes, as if they had signed in.
+func ParseAPIKey(ctx context.Context, key string) (*models.AppClaims, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	apiKey, err := repositories.FindAPIKeyByHash(ctx, utils.HashToken(key))
+
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := repositories.FindUserById(ctx, apiKey.UserID)
+
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &models.AppClaims{
+		UserId:   user.Id,
+		Role:     user.Role,
+		Scopes:   apiKey.Scopes,
+		APIKeyId: apiKey.ID,
+	}
+
+	if apiKey.ExpiresAt != nil {
+		claims.ExpiresAt = apiKey.ExpiresAt.Unix()
+	}
+
+	return claims, nil
+}
+
+// requiredScope is the scope an API key needs for a request with the method.
+func requiredScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.ReadScope
+	default:
+		return models.WriteScope
+	}
+}
+
 func AuthMiddleware(s server.Server) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -37,28 +126,39 @@ func AuthMiddleware(s server.Server) func(next http.Handler) http.Handler {
 			}
 
 			authorizationToken := r.Header.Get("Authorization")
-			tokenString := strings.Replace(authorizationToken, "Bearer ", "", 1)
 
-			token, err := jwt.ParseWithClaims(
-				tokenString,
-				&models.AppClaims{},
-				func(token *jwt.Token) (interface{}, error) {
-					return []byte(s.Config().JWTSecret), nil
-				},
-			)
+			var claims *models.AppClaims
+			var err error
 
-			if err != nil || !token.Valid {
+			if key, ok := strings.CutPrefix(authorizationToken, "ApiKey "); ok {
+				claims, err = ParseAPIKey(r.Context(), key)
+			} else {
+				tokenString := strings.Replace(authorizationToken, "Bearer ", "", 1)
+				claims, err = ParseToken(r.Context(), s, tokenString)
+			}
+
+			if err != nil {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			claims, ok := token.Claims.(*models.AppClaims)
-
-			if !ok {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if !claims.HasScope(requiredScope(r.Method)) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
 
+			if claims.APIKeyId != 0 {
+				if err := repositories.TouchAPIKey(r.Context(), claims.APIKeyId); err != nil {
+					log.Println("Error touching api key: ", err)
+				}
+			}
+
+			if claims.SessionId != "" {
+				if err := repositories.TouchSession(r.Context(), claims.SessionId); err != nil {
+					log.Println("Error touching session: ", err)
+				}
+			}
+
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, ClaimsKey, claims)
 			r = r.WithContext(ctx)
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"tincho.dev/rest-ws/models"
+)
+
+// RequireRole only lets through requests authenticated by a user with one of
+// the given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsKey).(*models.AppClaims)
+
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !claims.HasRole(roles...) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"tincho.dev/rest-ws/database"
+	"tincho.dev/rest-ws/database/sqlite"
+	"tincho.dev/rest-ws/migrate"
+)
+
+const migrateUsage = `usage: rest-ws migrate <command>
+
+commands:
+  up [n]         apply the next n pending migrations, or all of them
+  down [n]       roll back the last n migrations, 1 by default
+  status         list the migrations and whether they were applied
+  create <name>  add an empty migration`
+
+// runMigrate runs the migrate subcommand with the arguments after it.
+func runMigrate(databaseURL string, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	scheme := database.Scheme(databaseURL)
+
+	if scheme == "" {
+		log.Fatal("Unsupported DATABASE_URL, use postgres:// or sqlite://")
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal(migrateUsage)
+		}
+
+		dir := database.PostgresMigrationsDir
+
+		if scheme == database.SQLiteScheme {
+			dir = sqlite.MigrationsDir
+		}
+
+		files, err := migrate.Create(dir, args[1])
+
+		if err != nil {
+			log.Fatal("Error creating migration: ", err)
+		}
+
+		for _, file := range files {
+			fmt.Println("Created", file)
+		}
+
+		return
+	}
+
+	migrator, err := openMigrator(scheme, databaseURL)
+
+	if err != nil {
+		log.Fatal("Error loading migrations: ", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, migrateCount(args, 0))
+
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+
+		if err != nil {
+			log.Fatal("Error applying migrations: ", err)
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("The database is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, migrateCount(args, 1))
+
+		for _, m := range reverted {
+			fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+
+		if err != nil {
+			log.Fatal("Error rolling back migrations: ", err)
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+
+		if err != nil {
+			log.Fatal("Error reading migrations: ", err)
+		}
+
+		for _, s := range status {
+			appliedAt := "pending"
+
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
+
+func openMigrator(scheme string, databaseURL string) (*migrate.Migrator, error) {
+	if scheme == database.SQLiteScheme {
+		repo, err := sqlite.NewSQLite(databaseURL)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return repo.Migrator()
+	}
+
+	repo, err := database.NewPostgres(databaseURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Migrator()
+}
+
+// migrateCount parses the optional number of migrations of up and down.
+func migrateCount(args []string, defaultCount int) int {
+	if len(args) < 2 {
+		return defaultCount
+	}
+
+	n, err := strconv.Atoi(args[1])
+
+	if err != nil || n < 1 {
+		log.Fatal("Invalid number of migrations: ", args[1])
+	}
+
+	return n
+}
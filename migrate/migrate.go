@@ -0,0 +1,353 @@
+// Package migrate applies numbered SQL migrations and records them in the
+// schema_migrations table.
+//
+// Migrations are pairs of files named like 0001_create_users.up.sql and
+// 0001_create_users.down.sql. Each one runs in its own transaction.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNoMigrations = errors.New("no migrations to apply")
+	ErrUnknown      = errors.New("the database has migrations this binary doesn't know")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, nil when it is pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Locker keeps other processes from migrating the same database at the same
+// time, for as long as the connection holds the lock.
+type Locker interface {
+	Lock(ctx context.Context, conn *sql.Conn) error
+	Unlock(ctx context.Context, conn *sql.Conn) error
+}
+
+type Migrator struct {
+	db         *sql.DB
+	locker     Locker
+	migrations []Migration
+}
+
+// Load reads the migrations in the root of fsys, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+
+		if err != nil {
+			return nil, err
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func New(db *sql.DB, fsys fs.FS, locker Locker) (*Migrator, error) {
+	migrations, err := Load(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, locker: locker, migrations: migrations}, nil
+}
+
+// Up applies up to n pending migrations, or all of them when n is 0, and
+// returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if n > 0 && len(applied) == n {
+				break
+			}
+
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err := m.apply(ctx, conn, migration, migration.Up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+				migration.Version, migration.Name, time.Now().UTC())
+
+			if err != nil {
+				return err
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations and returns them.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			migration := m.migrations[i]
+
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.apply(ctx, conn, migration, migration.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+
+			if err != nil {
+				return err
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists every known migration and whether it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var status []Status
+
+	err := m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			s := Status{Migration: migration}
+
+			if appliedAt, ok := versions[migration.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+
+			status = append(status, s)
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+// locked runs fn holding the lock, with the versions already applied.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, versions map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	err = m.locker.Lock(ctx, conn)
+
+	if err != nil {
+		return fmt.Errorf("locking migrations: %w", err)
+	}
+
+	// The context may be done already, the lock has to be released anyway.
+	defer m.locker.Unlock(context.Background(), conn)
+
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`
+
+	_, err = conn.ExecContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	versions, err := appliedVersions(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	for version := range versions {
+		if !m.known(version) {
+			return fmt.Errorf("%w: %d", ErrUnknown, version)
+		}
+	}
+
+	return fn(conn, versions)
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// apply runs the migration script and records it in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, script)
+
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, record, args...)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Create adds an empty migration to dir, numbered after the last one, and
+// returns the paths of its files.
+func Create(dir string, name string) ([]string, error) {
+	if !regexp.MustCompile(`^\w+$`).MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q, use letters, digits and underscores", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+
+	if err != nil {
+		return nil, err
+	}
+
+	version := int64(1)
+
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s\n", filepath.Base(file))
+
+		err := os.WriteFile(file, []byte(content), 0644)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,24 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+)
+
+// postgresLockId identifies the advisory lock of the migrations.
+const postgresLockId = 7398210451
+
+// PostgresLock holds a session level advisory lock while migrating.
+type PostgresLock struct{}
+
+func (PostgresLock) Lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockId)
+
+	return err
+}
+
+func (PostgresLock) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockId)
+
+	return err
+}
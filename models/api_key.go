@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	// APIKeyPrefix starts every API key, so they are easy to recognize, for
+	// example by secret scanners.
+	APIKeyPrefix = "rws_"
+
+	// ReadScope allows safe requests (GET, HEAD and OPTIONS) and WriteScope
+	// any other. A key without scopes allows everything.
+	ReadScope  = "read"
+	WriteScope = "write"
+)
+
+// APIKey lets machine clients authenticate as a user. Only the hash of the
+// key is stored, and its first characters to tell keys apart.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
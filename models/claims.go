@@ -2,7 +2,53 @@ package models
 
 import "github.com/golang-jwt/jwt"
 
+// AppClaims identifies the token with the standard "jti" claim (Id) and the
+// sign in it descends from with "sid", so both can be revoked. "iat_us" is
+// when it was issued in microseconds, as "iat" only has whole seconds.
+//
+// Requests authenticated with an API key get claims built from the key, with
+// its scopes and APIKeyId set.
 type AppClaims struct {
-	UserId int64 `json:"user_id"`
+	UserId    int64    `json:"user_id"`
+	SessionId string   `json:"sid,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	APIKeyId  int64    `json:"-"`
+
+	IssuedAtMicros int64 `json:"iat_us,omitempty"`
 	jwt.StandardClaims
 }
+
+// HasRole reports whether the token was issued to a user with one of the
+// given roles. Tokens issued before roles existed belong to regular users.
+func (c *AppClaims) HasRole(roles ...string) bool {
+	role := c.Role
+
+	if role == "" {
+		role = UserRole
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope reports whether the claims allow the scope. Claims without scopes
+// allow everything, and the write scope includes reading.
+func (c *AppClaims) HasScope(scope string) bool {
+	if len(c.Scopes) == 0 {
+		return true
+	}
+
+	for _, s := range c.Scopes {
+		if s == scope || (s == WriteScope && scope == ReadScope) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EmailVerification confirms the address the user had when it was sent, so
+// it is worthless once they change their email.
+type EmailVerification struct {
+	ID        int64
+	UserID    int64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
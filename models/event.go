@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	PostCreatedEvent = "post.created"
+	PostUpdatedEvent = "post.updated"
+	PostDeletedEvent = "post.deleted"
+
+	PresenceOnlineEvent  = "presence.online"
+	PresenceOfflineEvent = "presence.offline"
+
+	MessageCreatedEvent = "message.created"
+	MessageUnreadEvent  = "message.unread"
+
+	// TokensRevokedEvent is never sent to clients: it asks every instance
+	// to close the connections of the recipients whose token was revoked.
+	TokensRevokedEvent = "tokens.revoked"
+)
+
+const (
+	PostsTopic    = "posts"
+	PresenceTopic = "presence"
+)
+
+var topicPattern = regexp.MustCompile(`^(posts|posts:\d+|users:\d+:posts|presence)$`)
+
+type Event struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
+}
+
+// Notification is an event on its way to the WebSocket and Server-Sent Events
+// clients of every server instance. It is either published on topics or
+// addressed to specific users.
+type Notification struct {
+	Topics     []string `json:"topics,omitempty"`
+	Recipients []int64  `json:"recipients,omitempty"`
+	Event      Event    `json:"event"`
+}
+
+type PresencePayload struct {
+	UserId int64 `json:"user_id"`
+}
+
+func PostTopic(id int64) string {
+	return fmt.Sprintf("posts:%d", id)
+}
+
+func UserPostsTopic(userId int64) string {
+	return fmt.Sprintf("users:%d:posts", userId)
+}
+
+// PostTopics returns every topic an event about the given post is published on.
+func PostTopics(post *Post) []string {
+	return []string{PostsTopic, PostTopic(post.ID), UserPostsTopic(post.UserID)}
+}
+
+func IsValidTopic(topic string) bool {
+	return topicPattern.MatchString(topic)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// OIDCStateAudience is the audience of the tokens that keep the state of an
+// OpenID Connect sign in between the redirect and the callback, so they
+// can't be used as access tokens.
+const OIDCStateAudience = "oidc"
+
+// ExternalIdentity links a user to their account at an OpenID Connect
+// provider, identified by its issuer.
+type ExternalIdentity struct {
+	ID        int64
+	UserID    int64
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+type OIDCStateClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.StandardClaims
+}
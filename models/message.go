@@ -0,0 +1,10 @@
+package models
+
+type Message struct {
+	ID          int64   `json:"id"`
+	SenderID    int64   `json:"sender_id"`
+	RecipientID int64   `json:"recipient_id"`
+	Content     string  `json:"content"`
+	ReadAt      *string `json:"read_at"`
+	CreatedAt   string  `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// MFAChallengeAudience is the audience of the tokens returned by /signin to
+// users with two-factor authentication, so they can't be used as access
+// tokens.
+const MFAChallengeAudience = "mfa"
+
+// MFA is the TOTP secret of a user. It only protects sign ins once it has
+// been confirmed with a valid code.
+type MFA struct {
+	UserID      int64
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastStep is the last time step a code was accepted for, so a code
+	// can't be used twice.
+	LastStep int64
+}
+
+// MFAChallengeClaims identify the user who passed the first step of the
+// sign in and still has to send a code.
+type MFAChallengeClaims struct {
+	UserId int64 `json:"mfa_user_id"`
+	jwt.StandardClaims
+}
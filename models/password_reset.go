@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is a single sign in. Its id is the "sid" claim of the access tokens
+// and the family of the refresh tokens issued for it.
+type Session struct {
+	ID         string
+	UserID     int64
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RefreshToken is an opaque, single-use token. Every token obtained by
+// rotating another one belongs to the same family as the token it replaced.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -1,8 +1,19 @@
 package models
 
+import "time"
+
+const (
+	UserRole      = "user"
+	ModeratorRole = "moderator"
+	AdminRole     = "admin"
+)
+
 type User struct {
 	Id       int64  `json:"id"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
-	Posts    []Post `json:"posts"`
+	Role     string `json:"role"`
+	// EmailVerifiedAt is nil until the user follows the link sent on signup.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Posts           []Post     `json:"posts"`
 }
@@ -0,0 +1,145 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"tincho.dev/rest-ws/signing"
+)
+
+// audience is a single audience or a list of them, as both are allowed in
+// ID tokens.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(clientId string) bool {
+	for _, aud := range a {
+		if aud == clientId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IDToken holds the claims of an ID token this package uses.
+type IDToken struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+	Email           string   `json:"email"`
+	EmailVerified   bool     `json:"email_verified"`
+}
+
+func (t *IDToken) Valid() error {
+	if t.ExpiresAt == 0 || time.Now().Unix() > t.ExpiresAt {
+		return errors.New("token is expired")
+	}
+
+	return nil
+}
+
+// Verify checks the ID token was signed by the provider for this client and
+// carries the nonce sent with the authorization request.
+func (p *Provider) Verify(ctx context.Context, rawIDToken string, nonce string) (*IDToken, error) {
+	token, err := jwt.ParseWithClaims(rawIDToken, &IDToken{}, p.keyfunc(ctx))
+
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	idToken, ok := token.Claims.(*IDToken)
+
+	if !ok || idToken.Issuer != p.discovery.Issuer || idToken.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !idToken.Audience.contains(p.config.ClientID) {
+		return nil, ErrInvalidIDToken
+	}
+
+	if len(idToken.Audience) > 1 && idToken.AuthorizedParty != p.config.ClientID {
+		return nil, ErrInvalidIDToken
+	}
+
+	if idToken.Nonce == "" || idToken.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	return idToken, nil
+}
+
+// keyfunc resolves the provider key a token was signed with, fetching the
+// provider's keys again when it is unknown, as it may have rotated them.
+func (p *Provider) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		keys, err := p.keySet(ctx, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := keys.Keyfunc(token)
+
+		if !errors.Is(err, signing.ErrUnknownKey) {
+			return key, err
+		}
+
+		keys, err = p.keySet(ctx, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return keys.Keyfunc(token)
+	}
+}
+
+func (p *Provider) keySet(ctx context.Context, refresh bool) (*signing.KeySet, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stale := refresh && time.Since(p.keysFetchedAt) > KEYS_REFRESH_INTERVAL
+
+	if p.keys != nil && !stale {
+		return p.keys, nil
+	}
+
+	var jwks signing.JWKS
+
+	err := p.getJSON(ctx, p.discovery.JWKSURI, &jwks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = signing.KeySetFromJWKS(jwks)
+	p.keysFetchedAt = time.Now()
+
+	return p.keys, nil
+}
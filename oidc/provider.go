@@ -0,0 +1,205 @@
+// Package oidc signs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"tincho.dev/rest-ws/signing"
+)
+
+// KEYS_REFRESH_INTERVAL limits how often the provider's keys are fetched again
+// when a token is signed with an unknown key, so forged tokens can't be used
+// to flood the provider.
+const KEYS_REFRESH_INTERVAL = time.Minute
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes defaults to openid, email and profile.
+	Scopes []string
+}
+
+// discovery holds the fields of the provider's discovery document this
+// package uses.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider struct {
+	config    Config
+	discovery discovery
+	client    *http.Client
+
+	mutex         sync.Mutex
+	keys          *signing.KeySet
+	keysFetchedAt time.Time
+}
+
+// NewProvider reads the provider's discovery document.
+func NewProvider(ctx context.Context, config Config) (*Provider, error) {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	p := &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	issuer := strings.TrimSuffix(config.IssuerURL, "/")
+
+	err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &p.discovery)
+
+	if err != nil {
+		return nil, fmt.Errorf("reading discovery document: %w", err)
+	}
+
+	if strings.TrimSuffix(p.discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document issuer %q doesn't match %q", p.discovery.Issuer, config.IssuerURL)
+	}
+
+	if p.discovery.AuthorizationEndpoint == "" || p.discovery.TokenEndpoint == "" || p.discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	return p, nil
+}
+
+// Issuer identifies the provider, as found in its ID tokens.
+func (p *Provider) Issuer() string {
+	return p.discovery.Issuer
+}
+
+// Challenge returns the S256 PKCE code challenge of a code verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the provider's URL the user has to be sent to. The
+// state, nonce and code verifier have to be kept until the callback.
+func (p *Provider) AuthCodeURL(state string, nonce string, verifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", Challenge(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return p.discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Exchange trades the authorization code the provider sent to the callback
+// for the user's tokens.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d: %s", res.StatusCode, body)
+	}
+
+	var token Token
+
+	err = json.Unmarshal(body, &token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id token")
+	}
+
+	return &token, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
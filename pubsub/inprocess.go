@@ -0,0 +1,58 @@
+package pubsub
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"tincho.dev/rest-ws/models"
+)
+
+// InProcess delivers notifications to the subscribers of a single server
+// instance.
+type InProcess struct {
+	mutex       sync.RWMutex
+	subscribers map[chan *models.Notification]bool
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{
+		subscribers: make(map[chan *models.Notification]bool),
+	}
+}
+
+// Publish never waits for a subscriber: one that has fallen a whole buffer
+// behind misses the notification.
+func (p *InProcess) Publish(ctx context.Context, n *models.Notification) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for subscriber := range p.subscribers {
+		select {
+		case subscriber <- n:
+		default:
+			log.Println("Dropping notification for a slow subscriber: ", n.Event.Type)
+		}
+	}
+
+	return nil
+}
+
+func (p *InProcess) Subscribe(ctx context.Context) (<-chan *models.Notification, error) {
+	subscriber := make(chan *models.Notification, 256)
+
+	p.mutex.Lock()
+	p.subscribers[subscriber] = true
+	p.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.mutex.Lock()
+		delete(p.subscribers, subscriber)
+		close(subscriber)
+		p.mutex.Unlock()
+	}()
+
+	return subscriber, nil
+}
@@ -0,0 +1,26 @@
+package pubsub
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type PubSub interface {
+	Publish(ctx context.Context, n *models.Notification) error
+	Subscribe(ctx context.Context) (<-chan *models.Notification, error)
+}
+
+var implementation PubSub
+
+func SetPubSub(pubsub PubSub) {
+	implementation = pubsub
+}
+
+func Publish(ctx context.Context, n *models.Notification) error {
+	return implementation.Publish(ctx, n)
+}
+
+func Subscribe(ctx context.Context) (<-chan *models.Notification, error) {
+	return implementation.Subscribe(ctx)
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k *models.APIKey) error
+	FindAPIKeyById(ctx context.Context, id int64) (*models.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userId int64) ([]models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+	DeleteAPIKey(ctx context.Context, id int64) error
+	DeleteUserAPIKeys(ctx context.Context, userId int64) error
+}
+
+var apiKeyImplementation APIKeyRepository
+
+func SetAPIKeyRepository(repository APIKeyRepository) {
+	apiKeyImplementation = repository
+}
+
+func CreateAPIKey(ctx context.Context, k *models.APIKey) error {
+	return apiKeyImplementation.CreateAPIKey(ctx, k)
+}
+
+func FindAPIKeyById(ctx context.Context, id int64) (*models.APIKey, error) {
+	return apiKeyImplementation.FindAPIKeyById(ctx, id)
+}
+
+func FindAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return apiKeyImplementation.FindAPIKeyByHash(ctx, hash)
+}
+
+func ListAPIKeys(ctx context.Context, userId int64) ([]models.APIKey, error) {
+	return apiKeyImplementation.ListAPIKeys(ctx, userId)
+}
+
+func TouchAPIKey(ctx context.Context, id int64) error {
+	return apiKeyImplementation.TouchAPIKey(ctx, id)
+}
+
+func DeleteAPIKey(ctx context.Context, id int64) error {
+	return apiKeyImplementation.DeleteAPIKey(ctx, id)
+}
+
+func DeleteUserAPIKeys(ctx context.Context, userId int64) error {
+	return apiKeyImplementation.DeleteUserAPIKeys(ctx, userId)
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type EmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, v *models.EmailVerification) error
+	FindEmailVerificationByHash(ctx context.Context, hash string) (*models.EmailVerification, error)
+	// UseEmailVerification marks the verification as used and reports whether
+	// it was still unused, so a link can't be followed twice.
+	UseEmailVerification(ctx context.Context, id int64) (bool, error)
+}
+
+var emailVerificationImplementation EmailVerificationRepository
+
+func SetEmailVerificationRepository(repository EmailVerificationRepository) {
+	emailVerificationImplementation = repository
+}
+
+func CreateEmailVerification(ctx context.Context, v *models.EmailVerification) error {
+	return emailVerificationImplementation.CreateEmailVerification(ctx, v)
+}
+
+func FindEmailVerificationByHash(ctx context.Context, hash string) (*models.EmailVerification, error) {
+	return emailVerificationImplementation.FindEmailVerificationByHash(ctx, hash)
+}
+
+func UseEmailVerification(ctx context.Context, id int64) (bool, error) {
+	return emailVerificationImplementation.UseEmailVerification(ctx, id)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import "errors"
+
+// The repositories return these errors, whatever the backend, so callers
+// don't depend on the errors of a database driver.
+var (
+	// ErrNotFound is returned when the row doesn't exist, or when a new one
+	// refers to a row that doesn't, like a post of an unknown user.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a unique constraint is violated, like a
+	// second user with the same email.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is returned when the operation isn't allowed.
+	ErrForbidden = errors.New("forbidden")
+)
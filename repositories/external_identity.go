@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type ExternalIdentityRepository interface {
+	CreateExternalIdentity(ctx context.Context, i *models.ExternalIdentity) error
+	FindExternalIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error)
+}
+
+var externalIdentityImplementation ExternalIdentityRepository
+
+func SetExternalIdentityRepository(repository ExternalIdentityRepository) {
+	externalIdentityImplementation = repository
+}
+
+func CreateExternalIdentity(ctx context.Context, i *models.ExternalIdentity) error {
+	return externalIdentityImplementation.CreateExternalIdentity(ctx, i)
+}
+
+func FindExternalIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error) {
+	return externalIdentityImplementation.FindExternalIdentity(ctx, issuer, subject)
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type MessageRepository interface {
+	CreateMessage(ctx context.Context, m *models.Message) error
+	ListMessages(ctx context.Context, userId int64, otherUserId int64, offset int64, limit int64) ([]models.Message, error)
+	FindUnreadMessages(ctx context.Context, recipientId int64) ([]models.Message, error)
+	MarkMessagesAsRead(ctx context.Context, recipientId int64, senderId int64) error
+}
+
+var messageImplementation MessageRepository
+
+func SetMessageRepository(repository MessageRepository) {
+	messageImplementation = repository
+}
+
+func CreateMessage(ctx context.Context, m *models.Message) error {
+	return messageImplementation.CreateMessage(ctx, m)
+}
+
+func ListMessages(ctx context.Context, userId int64, otherUserId int64, offset int64, limit int64) ([]models.Message, error) {
+	return messageImplementation.ListMessages(ctx, userId, otherUserId, offset, limit)
+}
+
+func FindUnreadMessages(ctx context.Context, recipientId int64) ([]models.Message, error) {
+	return messageImplementation.FindUnreadMessages(ctx, recipientId)
+}
+
+func MarkMessagesAsRead(ctx context.Context, recipientId int64, senderId int64) error {
+	return messageImplementation.MarkMessagesAsRead(ctx, recipientId, senderId)
+}
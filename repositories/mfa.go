@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type MFARepository interface {
+	// SaveMFASecret stores a new unconfirmed secret for the user, replacing
+	// any previous one.
+	SaveMFASecret(ctx context.Context, userId int64, secret string) error
+	FindMFA(ctx context.Context, userId int64) (*models.MFA, error)
+	// ConfirmMFA enables the secret and replaces the user's recovery codes.
+	ConfirmMFA(ctx context.Context, userId int64, recoveryCodeHashes []string) error
+	DeleteMFA(ctx context.Context, userId int64) error
+	// UseMFAStep records a code was accepted for the step and reports
+	// whether no code had been accepted for it or a later step before.
+	UseMFAStep(ctx context.Context, userId int64, step int64) (bool, error)
+	// UseRecoveryCode marks the code as used and reports whether it existed
+	// and was still unused.
+	UseRecoveryCode(ctx context.Context, userId int64, hash string) (bool, error)
+}
+
+var mfaImplementation MFARepository
+
+func SetMFARepository(repository MFARepository) {
+	mfaImplementation = repository
+}
+
+func SaveMFASecret(ctx context.Context, userId int64, secret string) error {
+	return mfaImplementation.SaveMFASecret(ctx, userId, secret)
+}
+
+func FindMFA(ctx context.Context, userId int64) (*models.MFA, error) {
+	return mfaImplementation.FindMFA(ctx, userId)
+}
+
+func ConfirmMFA(ctx context.Context, userId int64, recoveryCodeHashes []string) error {
+	return mfaImplementation.ConfirmMFA(ctx, userId, recoveryCodeHashes)
+}
+
+func DeleteMFA(ctx context.Context, userId int64) error {
+	return mfaImplementation.DeleteMFA(ctx, userId)
+}
+
+func UseMFAStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	return mfaImplementation.UseMFAStep(ctx, userId, step)
+}
+
+func UseRecoveryCode(ctx context.Context, userId int64, hash string) (bool, error) {
+	return mfaImplementation.UseRecoveryCode(ctx, userId, hash)
+}
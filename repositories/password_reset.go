@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, p *models.PasswordReset) error
+	FindPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordReset, error)
+	// UsePasswordReset marks the reset as used and reports whether it was
+	// still unused, so a token can't be redeemed twice.
+	UsePasswordReset(ctx context.Context, id int64) (bool, error)
+}
+
+var passwordResetImplementation PasswordResetRepository
+
+func SetPasswordResetRepository(repository PasswordResetRepository) {
+	passwordResetImplementation = repository
+}
+
+func CreatePasswordReset(ctx context.Context, p *models.PasswordReset) error {
+	return passwordResetImplementation.CreatePasswordReset(ctx, p)
+}
+
+func FindPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordReset, error) {
+	return passwordResetImplementation.FindPasswordResetByHash(ctx, hash)
+}
+
+func UsePasswordReset(ctx context.Context, id int64) (bool, error) {
+	return passwordResetImplementation.UsePasswordReset(ctx, id)
+}
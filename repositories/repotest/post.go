@@ -0,0 +1,175 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+// PostRepository is what the post suite needs, as posts belong to users.
+type PostRepository interface {
+	repositories.UserRepository
+	repositories.PostRepository
+}
+
+// RunPostRepositorySuite runs the post repository contract. The factory
+// is called once per test and has to return an empty repository.
+func RunPostRepositorySuite(t *testing.T, factory func(t *testing.T) PostRepository) {
+	t.Run("create and find", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+
+		if post.ID == 0 || post.CreatedAt == "" || post.UpdatedAt == "" {
+			t.Fatalf("CreatePost() = %+v, want an id and the timestamps", post)
+		}
+
+		found, err := repo.FindPostById(ctx, post.ID)
+
+		if err != nil {
+			t.Fatalf("FindPostById() error = %v", err)
+		}
+
+		if found.Title != post.Title || found.Content != post.Content || found.UserID != author.Id {
+			t.Errorf("FindPostById() = %+v, want %+v", found, post)
+		}
+
+		if posts, err := repo.FindAllPosts(ctx); err != nil || len(posts) != 1 || posts[0].ID != post.ID {
+			t.Errorf("FindAllPosts() = %+v, %v, want post %d", posts, err, post.ID)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+
+		if err := repo.CreatePost(ctx, &models.Post{Title: "Orphan", Content: "Content", UserID: author.Id + 1000}); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("CreatePost() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+
+		if _, err := repo.FindPostById(ctx, post.ID+1000); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("FindPostById() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		missing := &models.Post{ID: post.ID + 1000, Title: "Missing", Content: "Content", UserID: author.Id}
+
+		if err := repo.UpdatePost(ctx, missing); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("UpdatePost() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if err := repo.DeletePost(ctx, missing.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("DeletePost() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if posts, _ := repo.FindAllPosts(ctx); len(posts) != 1 {
+			t.Errorf("FindAllPosts() = %d posts, want 1", len(posts))
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+
+		post.Title = "New title"
+		post.Content = "New content"
+
+		if err := repo.UpdatePost(ctx, post); err != nil {
+			t.Fatalf("UpdatePost() error = %v", err)
+		}
+
+		found, err := repo.FindPostById(ctx, post.ID)
+
+		if err != nil || found.Title != "New title" || found.Content != "New content" || found.UserID != author.Id {
+			t.Errorf("FindPostById() = %+v, %v, want %+v", found, err, post)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+		other := createPost(t, repo, author.Id, "Other")
+
+		if err := repo.DeletePost(ctx, post.ID); err != nil {
+			t.Fatalf("DeletePost() error = %v", err)
+		}
+
+		if _, err := repo.FindPostById(ctx, post.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("FindPostById() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if posts, _ := repo.FindAllPosts(ctx); len(posts) != 1 || posts[0].ID != other.ID {
+			t.Errorf("FindAllPosts() = %+v, want only post %d", posts, other.ID)
+		}
+	})
+
+	t.Run("user posts", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		reader := createUser(t, repo, "reader@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+		createPost(t, repo, reader.Id, "Other")
+
+		user, err := repo.FindUserById(ctx, author.Id)
+
+		if err != nil || len(user.Posts) != 1 || user.Posts[0].ID != post.ID {
+			t.Errorf("FindUserById() = %+v, %v, want post %d", user, err, post.ID)
+		}
+	})
+
+	t.Run("cascade delete", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		author := createUser(t, repo, "author@example.com")
+		reader := createUser(t, repo, "reader@example.com")
+		post := createPost(t, repo, author.Id, "Title")
+		other := createPost(t, repo, reader.Id, "Other")
+
+		if err := repo.DeleteOneUser(ctx, author.Id); err != nil {
+			t.Fatalf("DeleteOneUser() error = %v", err)
+		}
+
+		if _, err := repo.FindPostById(ctx, post.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("FindPostById() of a post of the deleted user error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if posts, _ := repo.FindAllPosts(ctx); len(posts) != 1 || posts[0].ID != other.ID {
+			t.Errorf("FindAllPosts() = %+v, want only post %d", posts, other.ID)
+		}
+	})
+}
+
+func createPost(t *testing.T, repo repositories.PostRepository, userId int64, title string) *models.Post {
+	t.Helper()
+
+	post := &models.Post{Title: title, Content: "Content", UserID: userId}
+
+	if err := repo.CreatePost(context.Background(), post); err != nil {
+		t.Fatalf("CreatePost(%q) error = %v", title, err)
+	}
+
+	return post
+}
@@ -0,0 +1,214 @@
+// Package repotest checks that the implementations of the repositories
+// behave the same. Every storage backend runs these suites from its tests.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+// RunUserRepositorySuite runs the user repository contract. The factory
+// is called once per test and has to return an empty repository.
+func RunUserRepositorySuite(t *testing.T, factory func(t *testing.T) repositories.UserRepository) {
+	t.Run("create and find", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		user := createUser(t, repo, "user@example.com")
+
+		if user.Id == 0 || user.Role != models.UserRole {
+			t.Fatalf("CreateUser() = %+v, want an id and the %q role", user, models.UserRole)
+		}
+
+		found, err := repo.FindUserById(ctx, user.Id)
+
+		if err != nil {
+			t.Fatalf("FindUserById() error = %v", err)
+		}
+
+		if found.Email != user.Email || found.Password != user.Password || found.Role != user.Role || found.EmailVerifiedAt != nil || len(found.Posts) != 0 {
+			t.Errorf("FindUserById() = %+v, want %+v", found, user)
+		}
+
+		found, err = repo.GetUserByEmail(ctx, user.Email)
+
+		if err != nil || found.Id != user.Id {
+			t.Errorf("GetUserByEmail() = %+v, %v, want user %d", found, err, user.Id)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		user := createUser(t, repo, "user@example.com")
+
+		if _, err := repo.FindUserById(ctx, user.Id+1000); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("FindUserById() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if _, err := repo.GetUserByEmail(ctx, "missing@example.com"); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("GetUserByEmail() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		missing := &models.User{Id: user.Id + 1000, Email: "missing@example.com", Password: "hash", Role: models.UserRole}
+
+		if err := repo.UpdateOneUser(ctx, missing); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("UpdateOneUser() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if err := repo.DeleteOneUser(ctx, missing.Id); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("DeleteOneUser() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if users, _ := repo.FindAllUsers(ctx); len(users) != 1 {
+			t.Errorf("FindAllUsers() = %d users, want 1", len(users))
+		}
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		createUser(t, repo, "taken@example.com")
+
+		if err := repo.CreateUser(ctx, &models.User{Email: "taken@example.com", Password: "hash"}); !errors.Is(err, repositories.ErrConflict) {
+			t.Errorf("CreateUser() error = %v, want %v", err, repositories.ErrConflict)
+		}
+
+		other := createUser(t, repo, "other@example.com")
+		other.Email = "taken@example.com"
+
+		if err := repo.UpdateOneUser(ctx, other); !errors.Is(err, repositories.ErrConflict) {
+			t.Errorf("UpdateOneUser() error = %v, want %v", err, repositories.ErrConflict)
+		}
+
+		if found, err := repo.FindUserById(ctx, other.Id); err != nil || found.Email != "other@example.com" {
+			t.Errorf("FindUserById() = %+v, %v, want the email unchanged", found, err)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		var ids []int64
+
+		for i := 0; i < 5; i++ {
+			ids = append(ids, createUser(t, repo, fmt.Sprintf("user%d@example.com", i)).Id)
+		}
+
+		// The offset counts pages of limit users.
+		tests := []struct {
+			offset int64
+			limit  int64
+			want   []int64
+		}{
+			{0, 2, ids[0:2]},
+			{1, 2, ids[2:4]},
+			{2, 2, ids[4:5]},
+			{3, 2, nil},
+			{0, 10, ids},
+			{1, 10, nil},
+			{0, 0, nil},
+			{-1, 2, nil},
+			{0, -1, nil},
+		}
+
+		for _, tt := range tests {
+			users, err := repo.ListUsers(ctx, tt.offset, tt.limit)
+
+			if err != nil {
+				t.Errorf("ListUsers(%d, %d) error = %v", tt.offset, tt.limit, err)
+				continue
+			}
+
+			var got []int64
+
+			for _, u := range users {
+				got = append(got, u.Id)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ListUsers(%d, %d) = users %v, want %v", tt.offset, tt.limit, got, tt.want)
+			}
+		}
+
+		if users, err := repo.FindAllUsers(ctx); err != nil || len(users) != len(ids) {
+			t.Errorf("FindAllUsers() = %d users, %v, want %d", len(users), err, len(ids))
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		user := createUser(t, repo, "user@example.com")
+		verifiedAt := time.Now().Truncate(time.Second)
+
+		user.Email = "renamed@example.com"
+		user.Password = "new-hash"
+		user.Role = models.AdminRole
+		user.EmailVerifiedAt = &verifiedAt
+
+		if err := repo.UpdateOneUser(ctx, user); err != nil {
+			t.Fatalf("UpdateOneUser() error = %v", err)
+		}
+
+		found, err := repo.FindUserById(ctx, user.Id)
+
+		if err != nil {
+			t.Fatalf("FindUserById() error = %v", err)
+		}
+
+		if found.Email != user.Email || found.Password != user.Password || found.Role != user.Role {
+			t.Errorf("FindUserById() = %+v, want %+v", found, user)
+		}
+
+		if found.EmailVerifiedAt == nil || !found.EmailVerifiedAt.Equal(verifiedAt) {
+			t.Errorf("FindUserById() verified at %v, want %v", found.EmailVerifiedAt, verifiedAt)
+		}
+
+		if _, err := repo.GetUserByEmail(ctx, "user@example.com"); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("GetUserByEmail() of the former email error = %v, want %v", err, repositories.ErrNotFound)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		ctx := context.Background()
+		repo := factory(t)
+
+		user := createUser(t, repo, "user@example.com")
+		other := createUser(t, repo, "other@example.com")
+
+		if err := repo.DeleteOneUser(ctx, user.Id); err != nil {
+			t.Fatalf("DeleteOneUser() error = %v", err)
+		}
+
+		if _, err := repo.FindUserById(ctx, user.Id); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("FindUserById() error = %v, want %v", err, repositories.ErrNotFound)
+		}
+
+		if users, _ := repo.FindAllUsers(ctx); len(users) != 1 || users[0].Id != other.Id {
+			t.Errorf("FindAllUsers() = %+v, want only user %d", users, other.Id)
+		}
+	})
+}
+
+func createUser(t *testing.T, repo repositories.UserRepository, email string) *models.User {
+	t.Helper()
+
+	user := &models.User{Email: email, Password: "hash"}
+
+	if err := repo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser(%q) error = %v", email, err)
+	}
+
+	return user
+}
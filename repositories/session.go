@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, s *models.Session) error
+	FindSessionById(ctx context.Context, id string) (*models.Session, error)
+	ListActiveSessions(ctx context.Context, userId int64) ([]models.Session, error)
+	TouchSession(ctx context.Context, id string) error
+	ExtendSession(ctx context.Context, id string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, id string) error
+	RevokeUserSessions(ctx context.Context, userId int64) error
+}
+
+var sessionImplementation SessionRepository
+
+func SetSessionRepository(repository SessionRepository) {
+	sessionImplementation = repository
+}
+
+func CreateSession(ctx context.Context, s *models.Session) error {
+	return sessionImplementation.CreateSession(ctx, s)
+}
+
+func FindSessionById(ctx context.Context, id string) (*models.Session, error) {
+	return sessionImplementation.FindSessionById(ctx, id)
+}
+
+func ListActiveSessions(ctx context.Context, userId int64) ([]models.Session, error) {
+	return sessionImplementation.ListActiveSessions(ctx, userId)
+}
+
+func TouchSession(ctx context.Context, id string) error {
+	return sessionImplementation.TouchSession(ctx, id)
+}
+
+func ExtendSession(ctx context.Context, id string, expiresAt time.Time) error {
+	return sessionImplementation.ExtendSession(ctx, id, expiresAt)
+}
+
+func RevokeSession(ctx context.Context, id string) error {
+	return sessionImplementation.RevokeSession(ctx, id)
+}
+
+func RevokeUserSessions(ctx context.Context, userId int64) error {
+	return sessionImplementation.RevokeUserSessions(ctx, userId)
+}
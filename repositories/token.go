@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, t *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RotateRefreshToken marks the token as used and reports whether it was
+	// still usable, so two concurrent refreshes can't both succeed.
+	RotateRefreshToken(ctx context.Context, id int64) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) error
+	RevokeUserRefreshTokens(ctx context.Context, userId int64) error
+}
+
+var refreshTokenImplementation RefreshTokenRepository
+
+func SetRefreshTokenRepository(repository RefreshTokenRepository) {
+	refreshTokenImplementation = repository
+}
+
+func CreateRefreshToken(ctx context.Context, t *models.RefreshToken) error {
+	return refreshTokenImplementation.CreateRefreshToken(ctx, t)
+}
+
+func FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	return refreshTokenImplementation.FindRefreshTokenByHash(ctx, hash)
+}
+
+func RotateRefreshToken(ctx context.Context, id int64) (bool, error) {
+	return refreshTokenImplementation.RotateRefreshToken(ctx, id)
+}
+
+func RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	return refreshTokenImplementation.RevokeRefreshTokenFamily(ctx, familyId)
+}
+
+func RevokeUserRefreshTokens(ctx context.Context, userId int64) error {
+	return refreshTokenImplementation.RevokeUserRefreshTokens(ctx, userId)
+}
@@ -0,0 +1,66 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemory keeps revocations in the process. They are lost on restart and
+// aren't shared between instances, so it is meant for development and tests.
+type InMemory struct {
+	mutex  sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int64]time.Time
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int64]time.Time),
+	}
+}
+
+func (m *InMemory) RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+
+	// Expired tokens are rejected anyway, there is no need to remember them.
+	for revoked, expiration := range m.tokens {
+		if expiration.Before(now) {
+			delete(m.tokens, revoked)
+		}
+	}
+
+	m.tokens[id] = expiresAt
+
+	return nil
+}
+
+func (m *InMemory) RevokeUserTokens(ctx context.Context, userId int64, issuedBefore time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.users[userId] = issuedBefore.Truncate(time.Microsecond)
+
+	return nil
+}
+
+func (m *InMemory) IsTokenRevoked(ctx context.Context, jti string, sessionId string, userId int64, issuedAt time.Time) (bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, id := range []string{jti, sessionId} {
+		if _, ok := m.tokens[id]; ok && id != "" {
+			return true, nil
+		}
+	}
+
+	if issuedBefore, ok := m.users[userId]; ok && !issuedAt.After(issuedBefore) {
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,44 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+)
+
+type Store interface {
+	// RevokeToken revokes a single token, or every access token of a session
+	// when given its id, until expiresAt.
+	RevokeToken(ctx context.Context, id string, expiresAt time.Time) error
+	// RevokeUserTokens revokes every token issued to the user up to
+	// issuedBefore, to the microsecond.
+	RevokeUserTokens(ctx context.Context, userId int64, issuedBefore time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string, sessionId string, userId int64, issuedAt time.Time) (bool, error)
+}
+
+var implementation Store
+
+func SetStore(store Store) {
+	implementation = store
+}
+
+func RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	return implementation.RevokeToken(ctx, id, expiresAt)
+}
+
+func RevokeUserTokens(ctx context.Context, userId int64, issuedBefore time.Time) error {
+	return implementation.RevokeUserTokens(ctx, userId, issuedBefore)
+}
+
+// IsRevoked checks the claims of a token. Tokens without microseconds count
+// as issued at the start of their second.
+func IsRevoked(ctx context.Context, claims *models.AppClaims) (bool, error) {
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+
+	if claims.IssuedAtMicros != 0 {
+		issuedAt = time.UnixMicro(claims.IssuedAtMicros)
+	}
+
+	return implementation.IsTokenRevoked(ctx, claims.Id, claims.SessionId, claims.UserId, issuedAt)
+}
@@ -3,65 +3,425 @@ package server
 import (
 	"context"
 	"errors"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"tincho.dev/rest-ws/database"
+	"tincho.dev/rest-ws/database/sqlite"
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/mailer"
+	"tincho.dev/rest-ws/memory"
+	"tincho.dev/rest-ws/migrate"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/oidc"
+	"tincho.dev/rest-ws/pubsub"
 	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/revocation"
+	"tincho.dev/rest-ws/signing"
+	"tincho.dev/rest-ws/sse"
+	"tincho.dev/rest-ws/utils"
+	"tincho.dev/rest-ws/websocket"
+)
+
+const (
+	// EVENTS_BUFFER_SIZE is how many events the Server-Sent Events stream keeps
+	// for clients that reconnect with a Last-Event-ID.
+	EVENTS_BUFFER_SIZE = 100
+)
+
+const (
+	DatabaseStorage = "database"
+	InMemoryStorage = "memory"
+
+	InProcessPubSub = "inprocess"
+	PostgresPubSub  = "postgres"
+
+	InMemoryRevocation = "memory"
+	PostgresRevocation = "postgres"
+
+	InMemoryLockout = "memory"
+	PostgresLockout = "postgres"
+
+	LogMailer  = "log"
+	SMTPMailer = "smtp"
 )
 
 type Config struct {
-	Port        string
-	JWTSecret   string
+	Port      string
+	JWTSecret string
+	// JWTKeyFiles are PEM encoded RS256 or EdDSA keys. When set, tokens are
+	// signed with the first private key instead of JWTSecret.
+	JWTKeyFiles []string
+	// Storage selects where the data is kept, either DatabaseStorage (the
+	// default) or InMemoryStorage, which needs no database and loses
+	// everything on restart.
+	Storage string
+	// DatabaseURL is a postgres:// or a sqlite:// url, which selects the
+	// database backend.
 	DatabaseURL string
+	// AutoMigrate applies the pending migrations when the server starts.
+	AutoMigrate bool
+	// PubSub selects how events reach the other server instances, either
+	// InProcessPubSub (the default, a single instance) or PostgresPubSub.
+	PubSub string
+	// Revocation selects where revoked tokens are stored, either
+	// PostgresRevocation (the default) or InMemoryRevocation.
+	Revocation string
+	// Lockout selects where failed sign ins are counted, either
+	// PostgresLockout (the default) or InMemoryLockout.
+	Lockout string
+	// AppURL is the public URL of the API, used in the links sent by email.
+	AppURL string
+	// Mailer selects how emails are sent, either LogMailer (the default),
+	// which writes them to MailLogFile or stdout, or SMTPMailer.
+	Mailer       string
+	MailLogFile  string
+	MailFrom     string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// RequireVerifiedEmail stops users from creating posts until they have
+	// verified their email.
+	RequireVerifiedEmail bool
+	// PasswordPolicy is checked whenever a user chooses a password.
+	PasswordPolicy utils.PasswordPolicy
+	// OIDCIssuerURL enables signing in with an OpenID Connect provider,
+	// registered with OIDCClientID and OIDCClientSecret. OIDCRedirectURL
+	// defaults to the callback under AppURL.
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
 }
 
 type Server interface {
 	Config() *Config
+	Hub() *websocket.Hub
+	Events() *sse.Stream
+	Keys() *signing.KeySet
+	// OIDC is nil when signing in with an OpenID Connect provider is not
+	// configured.
+	OIDC() *oidc.Provider
 }
 
 type Broker struct {
 	config *Config
 	router *mux.Router
+	hub    *websocket.Hub
+	events *sse.Stream
+	keys   *signing.KeySet
+	oidc   *oidc.Provider
 }
 
 func (b *Broker) Config() *Config {
 	return b.config
 }
 
+func (b *Broker) Hub() *websocket.Hub {
+	return b.hub
+}
+
+func (b *Broker) Events() *sse.Stream {
+	return b.events
+}
+
+func (b *Broker) Keys() *signing.KeySet {
+	return b.keys
+}
+
+func (b *Broker) OIDC() *oidc.Provider {
+	return b.oidc
+}
+
 func NewServer(ctx context.Context, config *Config) (*Broker, error) {
 	if config.Port == "" {
 		return nil, errors.New("port is required")
 	}
 
-	if config.JWTSecret == "" {
-		return nil, errors.New("jwt secret is required")
+	if config.JWTSecret == "" && len(config.JWTKeyFiles) == 0 {
+		return nil, errors.New("jwt secret or key files are required")
+	}
+
+	if config.Storage == "" {
+		config.Storage = DatabaseStorage
+	}
+
+	if config.Storage != DatabaseStorage && config.Storage != InMemoryStorage {
+		return nil, errors.New("unknown storage")
 	}
 
-	if config.DatabaseURL == "" {
+	if config.Storage == DatabaseStorage && config.DatabaseURL == "" {
 		return nil, errors.New("database url is required")
 	}
 
+	if config.Storage == DatabaseStorage && database.Scheme(config.DatabaseURL) == "" {
+		return nil, errors.New("unsupported database url, use postgres:// or sqlite://")
+	}
+
+	// The pubsub, revocation and lockout stores can only use Postgres.
+	postgres := config.Storage == DatabaseStorage && database.Scheme(config.DatabaseURL) == database.PostgresScheme
+
+	if config.PubSub == "" {
+		config.PubSub = InProcessPubSub
+	}
+
+	if config.PubSub != InProcessPubSub && config.PubSub != PostgresPubSub {
+		return nil, errors.New("unknown pubsub backend")
+	}
+
+	if config.Revocation == "" {
+		config.Revocation = InMemoryRevocation
+
+		if postgres {
+			config.Revocation = PostgresRevocation
+		}
+	}
+
+	if config.Revocation != InMemoryRevocation && config.Revocation != PostgresRevocation {
+		return nil, errors.New("unknown revocation store")
+	}
+
+	if config.PasswordPolicy.MinLength < 0 {
+		return nil, errors.New("password min length can't be negative")
+	}
+
+	if config.Lockout == "" {
+		config.Lockout = InMemoryLockout
+
+		if postgres {
+			config.Lockout = PostgresLockout
+		}
+	}
+
+	if config.Lockout != InMemoryLockout && config.Lockout != PostgresLockout {
+		return nil, errors.New("unknown lockout store")
+	}
+
+	if !postgres && (config.PubSub == PostgresPubSub || config.Revocation == PostgresRevocation || config.Lockout == PostgresLockout) {
+		return nil, errors.New("the postgres pubsub, revocation and lockout stores need a postgres database")
+	}
+
+	if config.Mailer == "" {
+		config.Mailer = LogMailer
+	}
+
+	if config.Mailer != LogMailer && config.Mailer != SMTPMailer {
+		return nil, errors.New("unknown mailer")
+	}
+
+	if config.Mailer == SMTPMailer && (config.SMTPHost == "" || config.SMTPPort == "" || config.MailFrom == "") {
+		return nil, errors.New("smtp host, port and mail from are required")
+	}
+
+	keys := signing.NewHMACKeySet(config.JWTSecret)
+
+	if len(config.JWTKeyFiles) > 0 {
+		var err error
+		keys, err = signing.LoadKeySet(config.JWTKeyFiles)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var provider *oidc.Provider
+
+	if config.OIDCIssuerURL != "" {
+		if config.OIDCClientID == "" {
+			return nil, errors.New("oidc client id is required")
+		}
+
+		if config.OIDCRedirectURL == "" {
+			config.OIDCRedirectURL = strings.TrimSuffix(config.AppURL, "/") + "/auth/oidc/callback"
+		}
+
+		var err error
+		provider, err = oidc.NewProvider(ctx, oidc.Config{
+			IssuerURL:    config.OIDCIssuerURL,
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  config.OIDCRedirectURL,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Broker{
 		config: config,
 		router: mux.NewRouter(),
+		hub:    websocket.NewHub(),
+		events: sse.NewStream(EVENTS_BUFFER_SIZE),
+		keys:   keys,
+		oidc:   provider,
 	}, nil
 }
 
+// Dispatch hands every notification published by any instance to this
+// instance's WebSocket hub and Server-Sent Events stream.
+func (b *Broker) Dispatch(notifications <-chan *models.Notification) {
+	for n := range notifications {
+		if n.Event.Type == models.TokensRevokedEvent {
+			for _, userId := range n.Recipients {
+				b.hub.CloseRevoked(userId)
+				b.events.CloseRevoked(userId)
+			}
+
+			continue
+		}
+
+		for _, userId := range n.Recipients {
+			b.hub.SendToUser(userId, n.Event)
+		}
+
+		if len(n.Topics) > 0 {
+			b.hub.Publish(n.Event, n.Topics...)
+			b.events.Publish(n.Event)
+		}
+	}
+}
+
+func (b *Broker) mailLog() io.Writer {
+	if b.config.MailLogFile == "" {
+		return os.Stdout
+	}
+
+	file, err := os.OpenFile(b.config.MailLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+
+	if err != nil {
+		log.Fatal("Error opening mail log file: ", err)
+	}
+
+	return file
+}
+
 func (b *Broker) Start(binder func(s Server, r *mux.Router)) {
 	binder(b, b.router)
-	repo, err := database.NewPostgres(b.config.DatabaseURL)
+
+	// repo is only used by the Postgres stores, which need a Postgres
+	// database.
+	var repo *database.Postgres
+
+	switch {
+	case b.config.Storage == InMemoryStorage:
+		setRepositories(memory.NewMemory())
+	case database.Scheme(b.config.DatabaseURL) == database.SQLiteScheme:
+		setRepositories(b.openSQLite())
+	default:
+		repo = b.openPostgres()
+		setRepositories(repo)
+	}
+
+	if b.config.Revocation == PostgresRevocation {
+		revocation.SetStore(repo)
+	} else {
+		revocation.SetStore(revocation.NewInMemory())
+	}
+
+	if b.config.Lockout == PostgresLockout {
+		lockout.SetStore(repo)
+	} else {
+		lockout.SetStore(lockout.NewInMemory())
+	}
+
+	if b.config.Mailer == SMTPMailer {
+		mailer.SetMailer(mailer.NewSMTP(b.config.SMTPHost, b.config.SMTPPort, b.config.SMTPUsername, b.config.SMTPPassword, b.config.MailFrom))
+	} else {
+		mailer.SetMailer(mailer.NewLog(b.mailLog()))
+	}
+
+	if b.config.PubSub == PostgresPubSub {
+		pubsub.SetPubSub(repo)
+	} else {
+		pubsub.SetPubSub(pubsub.NewInProcess())
+	}
+
+	notifications, err := pubsub.Subscribe(context.Background())
 
 	if err != nil {
-		log.Fatal("Error creating database: ", err)
+		log.Fatal("Error subscribing to events: ", err)
 	}
 
-	repositories.SetUserRepository(repo)
-	repositories.SetPostRepository(repo)
+	go b.hub.Run()
+	go b.Dispatch(notifications)
 	log.Println("Server is running on port", b.config.Port)
 
 	if err := http.ListenAndServe(b.config.Port, b.router); err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
 }
+
+func (b *Broker) openPostgres() *database.Postgres {
+	repo, err := database.NewPostgres(b.config.DatabaseURL)
+
+	if err != nil {
+		log.Fatal("Error creating database: ", err)
+	}
+
+	if b.config.AutoMigrate {
+		autoMigrate(repo.Migrator())
+	}
+
+	return repo
+}
+
+func (b *Broker) openSQLite() *sqlite.SQLite {
+	repo, err := sqlite.NewSQLite(b.config.DatabaseURL)
+
+	if err != nil {
+		log.Fatal("Error creating database: ", err)
+	}
+
+	if b.config.AutoMigrate {
+		autoMigrate(repo.Migrator())
+	}
+
+	return repo
+}
+
+func autoMigrate(migrator *migrate.Migrator, err error) {
+	if err != nil {
+		log.Fatal("Error loading migrations: ", err)
+	}
+
+	applied, err := migrator.Up(context.Background(), 0)
+
+	if err != nil {
+		log.Fatal("Error applying migrations: ", err)
+	}
+
+	log.Println("Applied", len(applied), "migrations")
+}
+
+// storage is implemented by every backend of the repositories.
+type storage interface {
+	repositories.UserRepository
+	repositories.PostRepository
+	repositories.MessageRepository
+	repositories.RefreshTokenRepository
+	repositories.SessionRepository
+	repositories.PasswordResetRepository
+	repositories.EmailVerificationRepository
+	repositories.MFARepository
+	repositories.APIKeyRepository
+	repositories.ExternalIdentityRepository
+}
+
+func setRepositories(s storage) {
+	repositories.SetUserRepository(s)
+	repositories.SetPostRepository(s)
+	repositories.SetMessageRepository(s)
+	repositories.SetRefreshTokenRepository(s)
+	repositories.SetSessionRepository(s)
+	repositories.SetPasswordResetRepository(s)
+	repositories.SetEmailVerificationRepository(s)
+	repositories.SetMFARepository(s)
+	repositories.SetAPIKeyRepository(s)
+	repositories.SetExternalIdentityRepository(s)
+}
@@ -0,0 +1,124 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the public key described by the JWK. Only RSA keys for RS256
+// and Ed25519 keys for EdDSA are supported.
+func (j JWK) Key() (*Key, error) {
+	key := &Key{ID: j.Kid}
+
+	switch {
+	case j.Kty == "RSA" && (j.Alg == "" || j.Alg == jwt.SigningMethodRS256.Alg()):
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = ed25519.PublicKey(x)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
+
+// KeySetFromJWKS returns a KeySet that verifies tokens signed with any of the
+// signing keys of the JWKS, such as the ones published by another service.
+// Keys of unsupported types are skipped.
+func KeySetFromJWKS(jwks JWKS) *KeySet {
+	k := &KeySet{keys: make(map[string]*Key)}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.Key()
+
+		if err != nil {
+			continue
+		}
+
+		k.keys[key.ID] = key
+		k.order = append(k.order, key)
+	}
+
+	return k
+}
+
+// JWKS returns the public keys of the set. It is empty when tokens are signed
+// with a shared secret.
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range k.order {
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch public := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,169 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrNoSigningKey   = errors.New("no private key to sign with")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+)
+
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet signs tokens with a single key and verifies them with any of its
+// keys, so a new key can be introduced before the previous one is retired.
+// A KeySet made with NewHMACKeySet uses HS256 with a shared secret instead,
+// and one made with KeySetFromJWKS can only verify.
+type KeySet struct {
+	secret []byte
+	keys   map[string]*Key
+	order  []*Key
+	signer *Key
+}
+
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		secret: []byte(secret),
+		keys:   make(map[string]*Key),
+	}
+}
+
+// LoadKeySet reads RS256 and EdDSA keys from PEM files. Each key id is the
+// file name without its extension. The first file holding a private key is
+// used to sign; files holding only a public key are used to verify.
+func LoadKeySet(files []string) (*KeySet, error) {
+	k := &KeySet{keys: make(map[string]*Key)}
+
+	for _, file := range files {
+		key, err := loadKey(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", file, err)
+		}
+
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+
+		k.keys[key.ID] = key
+		k.order = append(k.order, key)
+
+		if k.signer == nil && key.PrivateKey != nil {
+			k.signer = key
+		}
+	}
+
+	if k.signer == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return k, nil
+}
+
+func loadKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{
+		ID: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key.PrivateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key.PrivateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key.PublicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = ErrUnsupportedKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := key.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		key.PublicKey = &private.PublicKey
+	case ed25519.PrivateKey:
+		key.PublicKey = private.Public()
+	case nil:
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	switch key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
+
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+
+	if k.signer == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(k.signer.Method, claims)
+	token.Header["kid"] = k.signer.ID
+
+	return token.SignedString(k.signer.PrivateKey)
+}
+
+// Keyfunc resolves the key a token was signed with. The algorithm has to
+// match the key's, otherwise a public key could be used as an HMAC secret.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if k.secret != nil {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, ErrUnknownKey
+		}
+
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+
+	if !ok || token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrUnknownKey
+	}
+
+	return key.PublicKey, nil
+}
@@ -0,0 +1,212 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/revocation"
+)
+
+const (
+	heartbeatPeriod = 30 * time.Second
+	retryInterval   = 3 * time.Second
+)
+
+type Event struct {
+	ID   int64
+	Type string
+	Data []byte
+}
+
+// revokedCheck is queued to the streams of a user whose tokens were revoked,
+// for them to check their own.
+var revokedCheck = Event{Type: models.TokensRevokedEvent}
+
+// Stream fans events out to Server-Sent Events clients and keeps the last
+// events in a bounded buffer so reconnecting clients can catch up. The ids
+// are only meaningful to the stream that assigned them, so the replay needs
+// clients to reconnect to the same process.
+type Stream struct {
+	mutex  sync.Mutex
+	lastId int64
+	size   int
+	buffer []Event
+	// subscribers maps each subscriber to the user it belongs to.
+	subscribers map[chan Event]int64
+}
+
+func NewStream(size int) *Stream {
+	return &Stream{
+		size:        size,
+		buffer:      make([]Event, 0, size),
+		subscribers: make(map[chan Event]int64),
+	}
+}
+
+func (s *Stream) Publish(event models.Event) {
+	data, err := json.Marshal(event.Payload)
+
+	if err != nil {
+		log.Println("Error marshalling stream event: ", err)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastId++
+	e := Event{ID: s.lastId, Type: event.Type, Data: data}
+
+	if len(s.buffer) == s.size {
+		s.buffer = append(s.buffer[:0], s.buffer[1:]...)
+	}
+
+	s.buffer = append(s.buffer, e)
+
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- e:
+		default:
+			// The client can't keep up; it will replay from its last id when
+			// it reconnects.
+			delete(s.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// CloseRevoked closes the streams of the user opened with a token that has
+// been revoked since.
+func (s *Stream) CloseRevoked(userId int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for subscriber, owner := range s.subscribers {
+		if owner != userId {
+			continue
+		}
+
+		select {
+		case subscriber <- revokedCheck:
+		default:
+			// The client is closed like a slow one; it can't reconnect with
+			// a revoked token.
+			delete(s.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// subscribe registers a new subscriber and returns the buffered events newer
+// than lastId. Both happen under the same lock so no event is missed or sent
+// twice.
+func (s *Stream) subscribe(userId int64, lastId int64) ([]Event, chan Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	backlog := []Event{}
+
+	for _, e := range s.buffer {
+		if e.ID > lastId {
+			backlog = append(backlog, e)
+		}
+	}
+
+	subscriber := make(chan Event, 64)
+	s.subscribers[subscriber] = userId
+
+	return backlog, subscriber
+}
+
+func (s *Stream) unsubscribe(subscriber chan Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.subscribers[subscriber]; ok {
+		delete(s.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// HandleSSE streams events to an already authenticated request until the
+// client goes away or the token it was opened with expires or is revoked.
+func (s *Stream) HandleSSE(w http.ResponseWriter, r *http.Request, claims *models.AppClaims) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastId, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	backlog, subscriber := s.subscribe(claims.UserId, lastId)
+	defer s.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryInterval.Milliseconds())
+
+	for _, e := range backlog {
+		write(w, e)
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatPeriod)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+
+	if claims.ExpiresAt != 0 {
+		timer := time.NewTimer(time.Until(time.Unix(claims.ExpiresAt, 0)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case e, ok := <-subscriber:
+			if !ok {
+				return
+			}
+
+			if e.Type == revokedCheck.Type {
+				revoked, err := revocation.IsRevoked(r.Context(), claims)
+
+				if err != nil {
+					log.Println("Error checking token revocation: ", err)
+				}
+
+				if revoked {
+					return
+				}
+
+				continue
+			}
+
+			write(w, e)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-expired:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func write(w http.ResponseWriter, e Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/utils"
+)
+
+func doAPIKey(r http.Handler, method string, path string, key string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(""))
+	req.Header.Set("Authorization", "ApiKey "+key)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestAPIKeys(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/me/api-keys", handlers.CreateAPIKeyHandler(s)).Methods("POST")
+		r.HandleFunc("/users/me/api-keys", handlers.ListAPIKeysHandler(s)).Methods("GET")
+		r.HandleFunc("/users/me/api-keys/{id}", handlers.DeleteAPIKeyHandler(s)).Methods("DELETE")
+	})
+
+	newTestRepositories(t)
+	user := &models.User{Email: "ci@example.com"}
+	repositories.CreateUser(context.Background(), user)
+
+	token := signToken(t, user.Id, time.Hour)
+
+	var created dto.CreateAPIKeyResponse
+
+	if code := decode(r, "POST", "/users/me/api-keys", token, `{"name":"ci","scopes":["read"]}`, &created); code != http.StatusCreated {
+		t.Fatalf("create status = %v, want %v", code, http.StatusCreated)
+	}
+
+	if !strings.HasPrefix(created.Key, models.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("key = %q with prefix %q", created.Key, created.Prefix)
+	}
+
+	if code := doBody(r, "POST", "/users/me/api-keys", token, `{"name":"ci","scopes":["admin"]}`); code != http.StatusBadRequest {
+		t.Errorf("unknown scope status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	if code := doBody(r, "POST", "/users/me/api-keys", token, `{"name":"ci","expires_at":"2000-01-01T00:00:00Z"}`); code != http.StatusBadRequest {
+		t.Errorf("past expiration status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	var keys []dto.APIKeyResponse
+
+	if code := decode(r, "GET", "/users/me/api-keys", token, "", &keys); code != http.StatusOK || len(keys) != 1 || keys[0].Id != created.Id {
+		t.Errorf("list status = %v with %+v, want the created key", code, keys)
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", created.Key); code != http.StatusOK {
+		t.Errorf("read with a read key status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := doAPIKey(r, "POST", "/protected", created.Key); code != http.StatusForbidden {
+		t.Errorf("write with a read key status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	if code := doAPIKey(r, "GET", "/users/me/api-keys", created.Key); code != http.StatusForbidden {
+		t.Errorf("list with a key status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", models.APIKeyPrefix+"unknown"); code != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	expired := models.APIKeyPrefix + "expired"
+	expiresAt := time.Now().Add(-time.Minute)
+	repositories.CreateAPIKey(context.Background(), &models.APIKey{UserID: user.Id, Name: "old", KeyHash: utils.HashToken(expired), ExpiresAt: &expiresAt})
+
+	if code := doAPIKey(r, "GET", "/protected", expired); code != http.StatusUnauthorized {
+		t.Errorf("expired key status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	path := fmt.Sprintf("/users/me/api-keys/%d", created.Id)
+
+	if code := do(r, "DELETE", path, signToken(t, user.Id+1, time.Hour)); code != http.StatusNotFound {
+		t.Errorf("delete by another user status = %v, want %v", code, http.StatusNotFound)
+	}
+
+	if code := do(r, "DELETE", path, token); code != http.StatusOK {
+		t.Fatalf("delete status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", created.Key); code != http.StatusUnauthorized {
+		t.Errorf("deleted key status = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	gorillaws "github.com/gorilla/websocket"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/pubsub"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+// newAuthRouter mounts the given routes behind AuthMiddleware, next to a
+// protected /protected route that only answers 200.
+func newAuthRouter(s server.Server, bind func(r *mux.Router)) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(middlewares.AuthMiddleware(s))
+	r.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	bind(r)
+
+	return r
+}
+
+func do(r http.Handler, method string, path string, token string) int {
+	return doBody(r, method, path, token, "")
+}
+
+func doBody(r http.Handler, method string, path string, token string, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestSignOutRevokesToken(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signout", handlers.SignOutHandler(s)).Methods("POST")
+	})
+
+	token := signToken(t, 1, time.Hour)
+	other := signToken(t, 1, time.Hour)
+
+	if code := do(r, "GET", "/protected", token); code != http.StatusOK {
+		t.Fatalf("status before sign out = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "POST", "/signout", token); code != http.StatusOK {
+		t.Fatalf("sign out status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "GET", "/protected", token); code != http.StatusUnauthorized {
+		t.Errorf("status after sign out = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code := do(r, "GET", "/protected", other); code != http.StatusOK {
+		t.Errorf("other token status after sign out = %v, want %v", code, http.StatusOK)
+	}
+}
+
+func TestRevokeAllSessionsClosesConnections(t *testing.T) {
+	s := newTestServer(t)
+	ws := newWebSocketServer(t, s)
+	events := httptest.NewServer(handlers.EventsHandler(s))
+	defer events.Close()
+
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/me/sessions/revoke-all", handlers.RevokeAllSessionsHandler(s)).Methods("POST")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifications, _ := pubsub.Subscribe(ctx)
+	go s.Dispatch(notifications)
+
+	newTestRepositories(t)
+	user := &models.User{Email: "user@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	repositories.CreateUser(context.Background(), user)
+	repositories.CreateUser(context.Background(), other)
+
+	token := signToken(t, user.Id, time.Hour)
+	revoked := dialHub(t, ws.URL, token)
+	kept := dialHub(t, ws.URL, signToken(t, other.Id, time.Hour))
+
+	// A reply means the hub registered the connection.
+	subscribe(t, revoked, models.PostsTopic)
+	subscribe(t, kept, models.PostsTopic)
+
+	resp, err := http.Get(events.URL + "?token=" + token)
+
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	// The retry line is sent once the stream is subscribed.
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Scan()
+
+	if code := do(r, "POST", "/users/me/sessions/revoke-all", token); code != http.StatusOK {
+		t.Fatalf("revoke-all status = %v, want %v", code, http.StatusOK)
+	}
+
+	revoked.SetReadDeadline(time.Now().Add(time.Second))
+
+	if _, _, err := revoked.ReadMessage(); !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Errorf("revoked WebSocket read error = %v, want a policy violation close", err)
+	}
+
+	closed := make(chan struct{})
+
+	go func() {
+		for scanner.Scan() {
+		}
+
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("the event stream wasn't closed after revoke-all")
+	}
+
+	kept.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+	if _, _, err := kept.ReadMessage(); gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Errorf("other user's WebSocket was closed: %v", err)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/me/sessions/revoke-all", handlers.RevokeAllSessionsHandler(s)).Methods("POST")
+	})
+
+	newTestRepositories(t)
+	user := &models.User{Email: "user@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	repositories.CreateUser(context.Background(), user)
+	repositories.CreateUser(context.Background(), other)
+
+	for _, id := range []string{"first", "second"} {
+		repositories.CreateSession(context.Background(), &models.Session{
+			ID:        id,
+			UserID:    user.Id,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+	}
+
+	first := signSessionToken(t, user.Id, "first", time.Hour)
+	second := signSessionToken(t, user.Id, "second", time.Hour)
+	otherUser := signToken(t, other.Id, time.Hour)
+	key := models.APIKeyPrefix + "revoked"
+	repositories.CreateAPIKey(context.Background(), &models.APIKey{UserID: user.Id, Name: "ci", KeyHash: utils.HashToken(key), Scopes: []string{models.ReadScope}})
+
+	if code := do(r, "POST", "/users/me/sessions/revoke-all", first); code != http.StatusOK {
+		t.Fatalf("revoke-all status = %v, want %v", code, http.StatusOK)
+	}
+
+	for _, token := range []string{first, second} {
+		if code := do(r, "GET", "/protected", token); code != http.StatusUnauthorized {
+			t.Errorf("status after revoke-all = %v, want %v", code, http.StatusUnauthorized)
+		}
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", key); code != http.StatusUnauthorized {
+		t.Errorf("API key status after revoke-all = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code := do(r, "GET", "/protected", otherUser); code != http.StatusOK {
+		t.Errorf("other user status after revoke-all = %v, want %v", code, http.StatusOK)
+	}
+
+	// The cutoff keeps microseconds, so a sign in right after is accepted.
+	if code := do(r, "GET", "/protected", signSessionToken(t, user.Id, "third", time.Hour)); code != http.StatusOK {
+		t.Errorf("new session status after revoke-all = %v, want %v", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"tincho.dev/rest-ws/authz"
+	"tincho.dev/rest-ws/models"
+)
+
+func TestCan(t *testing.T) {
+	owner := &models.AppClaims{UserId: 1, Role: models.UserRole}
+	stranger := &models.AppClaims{UserId: 2, Role: models.UserRole}
+	moderator := &models.AppClaims{UserId: 3, Role: models.ModeratorRole}
+	admin := &models.AppClaims{UserId: 4, Role: models.AdminRole}
+
+	post := authz.Post(&models.Post{ID: 10, UserID: 1})
+	user := authz.User(1)
+	session := authz.Session(&models.Session{ID: "abc", UserID: 1})
+
+	tests := []struct {
+		claims   *models.AppClaims
+		action   authz.Action
+		resource authz.Resource
+		want     bool
+	}{
+		{owner, authz.UpdateAction, post, true},
+		{owner, authz.DeleteAction, post, true},
+		{stranger, authz.UpdateAction, post, false},
+		{stranger, authz.DeleteAction, post, false},
+		{moderator, authz.UpdateAction, post, false},
+		{moderator, authz.DeleteAction, post, true},
+		{admin, authz.UpdateAction, post, true},
+		{owner, authz.UpdateAction, user, true},
+		{owner, authz.UpdateRoleAction, user, false},
+		{moderator, authz.DeleteAction, user, false},
+		{admin, authz.UpdateRoleAction, user, true},
+		{owner, authz.DeleteAction, session, true},
+		{admin, authz.DeleteAction, session, false},
+	}
+
+	for _, tt := range tests {
+		if got := authz.Can(context.Background(), tt.claims, tt.action, tt.resource); got != tt.want {
+			t.Errorf("Can(%v, %v, %v %v) = %v, want %v", tt.claims.Role, tt.action, tt.resource.Type, tt.resource.Id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+)
+
+func TestRepositoryErrorStatuses(t *testing.T) {
+	m := newTestRepositories(t)
+
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/{id}", handlers.FindOneUserHandler(s)).Methods("GET")
+		r.HandleFunc("/users/{id}", handlers.UpdateUserHandler(s)).Methods("PUT")
+		r.HandleFunc("/users/{id}", handlers.DeleteUserHandler(s)).Methods("DELETE")
+		r.HandleFunc("/users/{id}/messages", handlers.SendMessageHandler(s)).Methods("POST")
+		r.HandleFunc("/posts/{id}", handlers.FindOnePostHandler(s)).Methods("GET")
+	})
+
+	ctx := context.Background()
+	user := &models.User{Email: "user@example.com", Password: "hash"}
+	m.CreateUser(ctx, user)
+	m.CreateUser(ctx, &models.User{Email: "taken@example.com", Password: "hash"})
+	token := signToken(t, user.Id, time.Hour)
+	admin := signRoleToken(t, user.Id, models.AdminRole)
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"missing user", token, "GET", "/users/999", "", http.StatusNotFound},
+		{"missing post", token, "GET", "/posts/999", "", http.StatusNotFound},
+		{"email taken", token, "PUT", "/users/1", `{"email":"taken@example.com"}`, http.StatusConflict},
+		{"another user", token, "PUT", "/users/2", `{"email":"other@example.com"}`, http.StatusForbidden},
+		{"delete missing user", admin, "DELETE", "/users/999", "", http.StatusNotFound},
+		{"missing recipient", token, "POST", "/users/999/messages", `{"content":"Hello"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if code := doBody(r, tt.method, tt.path, tt.token, tt.body); code != tt.want {
+			t.Errorf("%s: status = %v, want %v", tt.name, code, tt.want)
+		}
+	}
+}
+
+// TestSignUpConcurrently checks that the unique email is enforced by the
+// repository, so only one of the concurrent sign ups succeeds.
+func TestSignUpConcurrently(t *testing.T) {
+	newTestRepositories(t)
+
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signup", handlers.SignUpHandler(s)).Methods("POST")
+	})
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 5)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			codes <- doBody(r, "POST", "/signup", "", `{"email":"race@example.com","password":"a-long-password"}`)
+		}()
+	}
+
+	wg.Wait()
+	close(codes)
+
+	created := 0
+
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Errorf("signup status = %v, want %v or %v", code, http.StatusOK, http.StatusConflict)
+		}
+	}
+
+	if created != 1 {
+		t.Errorf("%d sign ups succeeded, want 1", created)
+	}
+}
+
+// TestForbiddenStatuses checks that denials go through the error mapper
+// with a 403, and that the ones that explain themselves keep their reason.
+func TestForbiddenStatuses(t *testing.T) {
+	m := newTestRepositories(t)
+
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/{id}", handlers.UpdateUserHandler(s)).Methods("PUT")
+		r.HandleFunc("/users/me/password", handlers.ChangePasswordHandler(s)).Methods("PUT")
+	})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	user := &models.User{Email: "user@example.com", Password: string(hashedPassword)}
+	other := &models.User{Email: "other@example.com", Password: "hash"}
+	m.CreateUser(context.Background(), user)
+	m.CreateUser(context.Background(), other)
+	token := signToken(t, user.Id, time.Hour)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"another user", "PUT", fmt.Sprintf("/users/%d", other.Id), `{"email":"new@example.com"}`, "Forbidden"},
+		{"wrong password", "PUT", "/users/me/password", `{"current_password":"wrong-password","new_password":"new-password"}`, "Current password is incorrect"},
+	}
+
+	for _, tt := range tests {
+		var response map[string]string
+
+		if code := decode(r, tt.method, tt.path, token, tt.body, &response); code != http.StatusForbidden || response["error"] != tt.want {
+			t.Errorf("%s: status = %v with %q, want %v with %q", tt.name, code, response["error"], http.StatusForbidden, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/sse"
+)
+
+// readEventIds reads the stream until it has collected n event ids.
+func readEventIds(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+
+	ids := []string{}
+
+	for len(ids) < n && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) < n {
+		t.Fatalf("read %d events, want %d", len(ids), n)
+	}
+
+	return ids
+}
+
+func TestEventsReplayFromLastEventId(t *testing.T) {
+	s := newTestServer(t)
+	srv := httptest.NewServer(handlers.EventsHandler(s))
+	defer srv.Close()
+
+	for i := int64(1); i <= 3; i++ {
+		s.Events().Publish(models.Event{Type: models.PostCreatedEvent, Payload: models.Post{ID: i}})
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"?token="+signToken(t, 1, time.Hour), nil)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %v, want text/event-stream", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	if ids := readEventIds(t, scanner, 2); ids[0] != "2" || ids[1] != "3" {
+		t.Errorf("replayed ids = %v, want [2 3]", ids)
+	}
+
+	s.Events().Publish(models.Event{Type: models.PostDeletedEvent, Payload: models.Post{ID: 1}})
+
+	if ids := readEventIds(t, scanner, 1); ids[0] != "4" {
+		t.Errorf("live id = %v, want 4", ids[0])
+	}
+}
+
+func TestEventsRequiresToken(t *testing.T) {
+	s := newTestServer(t)
+	srv := httptest.NewServer(handlers.EventsHandler(s))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %v, want %v", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestEventsBufferIsBounded(t *testing.T) {
+	stream := sse.NewStream(2)
+
+	for i := int64(1); i <= 5; i++ {
+		stream.Publish(models.Event{Type: models.PostCreatedEvent, Payload: models.Post{ID: i}})
+	}
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	stream.HandleSSE(rec, req.WithContext(ctx), &models.AppClaims{})
+
+	body := rec.Body.String()
+
+	if strings.Count(body, "id: ") != 2 || !strings.Contains(body, "id: 4") || !strings.Contains(body, "id: 5") {
+		t.Errorf("replayed body = %q, want only ids 4 and 5", body)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+const testSecret = "secret"
+
+func newTestServer(t *testing.T) *server.Broker {
+	t.Helper()
+
+	s, err := server.NewServer(context.Background(), &server.Config{
+		Port:        ":0",
+		JWTSecret:   testSecret,
+		DatabaseURL: "postgres://localhost/test",
+	})
+
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	go s.Hub().Run()
+
+	return s
+}
+
+func signToken(t *testing.T, userId int64, expiresIn time.Duration) string {
+	t.Helper()
+
+	return signSessionToken(t, userId, "", expiresIn)
+}
+
+func signSessionToken(t *testing.T, userId int64, sessionId string, expiresIn time.Duration) string {
+	t.Helper()
+
+	return signClaims(t, &models.AppClaims{UserId: userId, SessionId: sessionId}, expiresIn)
+}
+
+func signRoleToken(t *testing.T, userId int64, role string) string {
+	t.Helper()
+
+	return signClaims(t, &models.AppClaims{UserId: userId, Role: role}, time.Hour)
+}
+
+// signClaims fills in the standard claims the way SignInHandler does and
+// signs them with the test secret.
+func signClaims(t *testing.T, claims *models.AppClaims, expiresIn time.Duration) string {
+	t.Helper()
+
+	jti, err := utils.GenerateRandomToken()
+
+	if err != nil {
+		t.Fatalf("GenerateRandomToken() error = %v", err)
+	}
+
+	now := time.Now()
+
+	claims.IssuedAtMicros = now.UnixMicro()
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        jti,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(expiresIn).Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	return token
+}
+
+func parseTestToken(tokenString string) (*models.AppClaims, error) {
+	claims := &models.AppClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(testSecret), nil
+	})
+
+	return claims, err
+}
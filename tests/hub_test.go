@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+)
+
+// newWebSocketServer serves WebSocketHandler until the test ends, on empty
+// repositories so no unread message of another test is delivered. Upgraded
+// connections outlive the server, so it waits for the handlers to return
+// too, before the next test swaps the repositories they use.
+func newWebSocketServer(t *testing.T, s server.Server) *httptest.Server {
+	t.Helper()
+
+	newTestRepositories(t)
+
+	var running sync.WaitGroup
+	handler := handlers.WebSocketHandler(s)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		running.Add(1)
+		defer running.Done()
+
+		handler(w, r)
+	}))
+
+	t.Cleanup(func() {
+		srv.Close()
+		running.Wait()
+	})
+
+	return srv
+}
+
+func dialHub(t *testing.T, url string, token string) *gorillaws.Conn {
+	t.Helper()
+
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"?token="+token, nil)
+
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readEvent(t *testing.T, conn *gorillaws.Conn) models.Event {
+	t.Helper()
+
+	var event models.Event
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+
+	return event
+}
+
+func TestHubSendToUser(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	target := dialHub(t, srv.URL, signToken(t, 1, time.Hour))
+	other := dialHub(t, srv.URL, signToken(t, 2, time.Hour))
+
+	waitRegistered(t, target)
+	waitRegistered(t, other)
+
+	s.Hub().SendToUser(1, models.Event{Type: "direct"})
+
+	if event := readEvent(t, target); event.Type != "direct" {
+		t.Errorf("event type = %v, want %v", event.Type, "direct")
+	}
+
+	other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+	if _, _, err := other.ReadMessage(); err == nil {
+		t.Errorf("other user received a message addressed to user 1")
+	}
+}
+
+func TestWebSocketHandlerAuth(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	// Missing token
+	_, resp, err := gorillaws.DefaultDialer.Dial(url, nil)
+
+	if err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Dial() without token should be rejected with 401")
+	}
+
+	// Token sent through the bearer subprotocol
+	dialer := gorillaws.Dialer{Subprotocols: []string{"bearer", signToken(t, 1, time.Hour)}}
+	conn, _, err := dialer.Dial(url, nil)
+
+	if err != nil {
+		t.Fatalf("Dial() with subprotocol token error = %v", err)
+	}
+
+	if conn.Subprotocol() != "bearer" {
+		t.Errorf("Subprotocol() = %v, want bearer", conn.Subprotocol())
+	}
+
+	conn.Close()
+
+	// The socket is closed once the token expires
+	conn = dialHub(t, srv.URL, signToken(t, 1, time.Second))
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, _, err = conn.ReadMessage()
+
+	if !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Errorf("ReadMessage() error = %v, want policy violation close", err)
+	}
+}
+
+// waitRegistered returns once the hub registered the connection, as it only
+// answers the frames of registered clients.
+func waitRegistered(t *testing.T, conn *gorillaws.Conn) {
+	t.Helper()
+
+	subscribe(t, conn, models.PostTopic(0))
+}
+
+// readEvents reads the events of the connection into a channel, which is
+// closed once reading fails.
+func readEvents(conn *gorillaws.Conn) <-chan models.Event {
+	events := make(chan models.Event, 16)
+
+	go func() {
+		defer close(events)
+
+		for {
+			var event models.Event
+
+			if err := conn.ReadJSON(&event); err != nil {
+				return
+			}
+
+			events <- event
+		}
+	}()
+
+	return events
+}
+
+func subscribe(t *testing.T, conn *gorillaws.Conn, topic string) {
+	t.Helper()
+
+	conn.WriteJSON(map[string]string{"action": "subscribe", "topic": topic})
+
+	if event := readEvent(t, conn); event.Type != "subscribed" {
+		t.Fatalf("subscribe(%v) reply = %v, want subscribed", topic, event.Type)
+	}
+}
+
+func TestHubTopicSubscriptions(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	all := dialHub(t, srv.URL, signToken(t, 1, time.Hour))
+	single := dialHub(t, srv.URL, signToken(t, 2, time.Hour))
+	author := dialHub(t, srv.URL, signToken(t, 3, time.Hour))
+
+	subscribe(t, all, models.PostsTopic)
+	subscribe(t, single, models.PostTopic(2))
+	subscribe(t, author, models.UserPostsTopic(7))
+
+	post := &models.Post{ID: 1, UserID: 7}
+	s.Hub().Publish(models.Event{Type: models.PostCreatedEvent, Payload: post}, models.PostTopics(post)...)
+
+	if event := readEvent(t, all); event.Type != models.PostCreatedEvent {
+		t.Errorf("posts subscriber event type = %v, want %v", event.Type, models.PostCreatedEvent)
+	}
+
+	if event := readEvent(t, author); event.Type != models.PostCreatedEvent {
+		t.Errorf("author subscriber event type = %v, want %v", event.Type, models.PostCreatedEvent)
+	}
+
+	single.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+	if _, _, err := single.ReadMessage(); err == nil {
+		t.Errorf("posts:2 subscriber received an event for post 1")
+	}
+}
+
+func TestHubRejectsInvalidTopic(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	conn := dialHub(t, srv.URL, signToken(t, 1, time.Hour))
+	conn.WriteJSON(map[string]string{"action": "subscribe", "topic": "users:1:messages"})
+
+	if event := readEvent(t, conn); event.Type != "error" {
+		t.Errorf("reply type = %v, want error", event.Type)
+	}
+}
+
+func TestHubPresence(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	observer := dialHub(t, srv.URL, signToken(t, 1, time.Hour))
+	subscribe(t, observer, models.PresenceTopic)
+	events := readEvents(observer)
+
+	firstTab := dialHub(t, srv.URL, signToken(t, 2, time.Hour))
+
+	select {
+	case event := <-events:
+		if event.Type != models.PresenceOnlineEvent {
+			t.Fatalf("event type = %v, want %v", event.Type, models.PresenceOnlineEvent)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no %v event", models.PresenceOnlineEvent)
+	}
+
+	secondTab := dialHub(t, srv.URL, signToken(t, 2, time.Hour))
+	waitRegistered(t, secondTab)
+
+	if !s.Hub().IsOnline(2) {
+		t.Errorf("IsOnline(2) = false, want true")
+	}
+
+	firstTab.Close()
+
+	select {
+	case event := <-events:
+		t.Errorf("event %v after closing one of two tabs, want none", event.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if !s.Hub().IsOnline(2) {
+		t.Errorf("IsOnline(2) = false after closing one of two tabs, want true")
+	}
+
+	secondTab.Close()
+
+	select {
+	case event := <-events:
+		if event.Type != models.PresenceOfflineEvent {
+			t.Errorf("event type = %v, want %v", event.Type, models.PresenceOfflineEvent)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no %v event", models.PresenceOfflineEvent)
+	}
+
+	if s.Hub().IsOnline(2) {
+		t.Errorf("IsOnline(2) = true after closing every tab, want false")
+	}
+}
+
+func TestWebSocketDeliversUnreadMessages(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	m := newTestRepositories(t)
+	sender := &models.User{Email: "sender@example.com", Password: "hash"}
+	recipient := &models.User{Email: "recipient@example.com", Password: "hash"}
+	m.CreateUser(context.Background(), sender)
+	m.CreateUser(context.Background(), recipient)
+	repositories.CreateMessage(context.Background(), &models.Message{SenderID: sender.Id, RecipientID: recipient.Id, Content: "Hi"})
+
+	conn := dialHub(t, srv.URL, signToken(t, recipient.Id, time.Hour))
+
+	var event struct {
+		Type    string           `json:"type"`
+		Payload []models.Message `json:"payload"`
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+
+	if event.Type != models.MessageUnreadEvent || len(event.Payload) != 1 {
+		t.Errorf("event = %v with %d messages, want %v with 1 message", event.Type, len(event.Payload), models.MessageUnreadEvent)
+	}
+}
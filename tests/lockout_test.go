@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	policy := lockout.Policy{Threshold: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%v) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// signInFrom signs in from the given IP and returns the response.
+func signInFrom(r http.Handler, ip string, email string, password string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"email":%q,"password":%q}`, email, password)
+	req := httptest.NewRequest("POST", "/signin", strings.NewReader(body))
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestSignInAccountLockout(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+		r.HandleFunc("/users/{id}/unlock", handlers.UnlockUserHandler(s)).Methods("POST")
+	})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("right-password"), bcrypt.MinCost)
+	newTestRepositories(t)
+	target := &models.User{Email: "target@example.com", Password: string(hashedPassword)}
+	repositories.CreateUser(context.Background(), target)
+	t.Cleanup(func() {
+		lockout.Reset(context.Background(), lockout.Account("target@example.com"), lockout.IP("10.6.0.1"))
+	})
+
+	for i := 0; i < lockout.AccountPolicy.Threshold; i++ {
+		if rec := signInFrom(r, "10.6.0.1", "target@example.com", "wrong-password"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("failed attempt %d status = %v, want %v", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	rec := signInFrom(r, "10.6.0.1", "TARGET@example.com", "right-password")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked account status = %v, want %v", rec.Code, http.StatusTooManyRequests)
+	}
+
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want %q", got, "30")
+	}
+
+	admin := signRoleToken(t, target.Id+1, models.AdminRole)
+	user := signToken(t, target.Id+2, time.Hour)
+	path := fmt.Sprintf("/users/%d/unlock", target.Id)
+
+	if code := do(r, "POST", path, user); code != http.StatusForbidden {
+		t.Errorf("unlock by a user status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	if code := do(r, "POST", path, admin); code != http.StatusOK {
+		t.Fatalf("unlock status = %v, want %v", code, http.StatusOK)
+	}
+
+	if rec := signInFrom(r, "10.6.0.1", "target@example.com", "right-password"); rec.Code != http.StatusOK {
+		t.Errorf("unlocked account status = %v, want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestSignInIPLockout(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+	})
+
+	t.Cleanup(func() {
+		lockout.Reset(context.Background(), lockout.IP("10.6.0.2"), lockout.IP("10.6.0.3"), lockout.Account("another@example.com"))
+
+		for i := 0; i < lockout.IPPolicy.Threshold; i++ {
+			lockout.Reset(context.Background(), lockout.Account(fmt.Sprintf("guess%d@example.com", i)))
+		}
+	})
+
+	// Spreading the guesses over many accounts doesn't avoid the lockout.
+	for i := 0; i < lockout.IPPolicy.Threshold; i++ {
+		if rec := signInFrom(r, "10.6.0.2", fmt.Sprintf("guess%d@example.com", i), "password-guess"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("failed attempt %d status = %v, want %v", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	if rec := signInFrom(r, "10.6.0.2", "another@example.com", "password-guess"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("locked IP status = %v, want %v", rec.Code, http.StatusTooManyRequests)
+	}
+
+	if rec := signInFrom(r, "10.6.0.3", "another@example.com", "password-guess"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("other IP status = %v, want %v", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSignInLockoutParallel(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+	})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("right-password"), bcrypt.MinCost)
+	newTestRepositories(t)
+	repositories.CreateUser(context.Background(), &models.User{Email: "parallel@example.com", Password: string(hashedPassword)})
+	t.Cleanup(func() {
+		lockout.Reset(context.Background(), lockout.Account("parallel@example.com"), lockout.IP("10.6.0.4"), lockout.IP("10.6.0.5"))
+	})
+
+	// Every attempt is counted before the password is checked, so a burst
+	// of guesses gets no more tries than the threshold.
+	var wg sync.WaitGroup
+	codes := make(chan int, 3*lockout.AccountPolicy.Threshold)
+
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			codes <- signInFrom(r, "10.6.0.4", "parallel@example.com", "wrong-password").Code
+		}()
+	}
+
+	wg.Wait()
+	close(codes)
+
+	guesses := 0
+
+	for code := range codes {
+		if code == http.StatusUnauthorized {
+			guesses++
+		}
+	}
+
+	if guesses != lockout.AccountPolicy.Threshold {
+		t.Errorf("checked %d guesses, want %d", guesses, lockout.AccountPolicy.Threshold)
+	}
+
+	lockout.Reset(context.Background(), lockout.Account("parallel@example.com"))
+
+	// Successful sign ins don't count against the IP.
+	for i := 0; i <= lockout.IPPolicy.Threshold; i++ {
+		if rec := signInFrom(r, "10.6.0.5", "parallel@example.com", "right-password"); rec.Code != http.StatusOK {
+			t.Fatalf("sign in %d status = %v, want %v", i+1, rec.Code, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/memory"
+	"tincho.dev/rest-ws/models"
+)
+
+func TestMemoryUniqueEmail(t *testing.T) {
+	ctx := context.Background()
+	m := memory.NewMemory()
+
+	// Only one of the concurrent sign ups with the same email may succeed.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs <- m.CreateUser(ctx, &models.User{Email: "same@example.com", Password: "hash"})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	created := 0
+
+	for err := range errs {
+		if err == nil {
+			created++
+		} else if !errors.Is(err, memory.ErrDuplicateEmail) {
+			t.Errorf("CreateUser() error = %v, want %v", err, memory.ErrDuplicateEmail)
+		}
+	}
+
+	if created != 1 {
+		t.Fatalf("created %d users with the same email, want 1", created)
+	}
+
+	other := &models.User{Email: "other@example.com", Password: "hash"}
+	m.CreateUser(ctx, other)
+	other.Email = "same@example.com"
+
+	if err := m.UpdateOneUser(ctx, other); !errors.Is(err, memory.ErrDuplicateEmail) {
+		t.Errorf("UpdateOneUser() error = %v, want %v", err, memory.ErrDuplicateEmail)
+	}
+}
+
+// TestMemorySignIn runs the sign up and sign in handlers against the memory
+// storage, as the server does with STORAGE=memory.
+func TestMemorySignIn(t *testing.T) {
+	newTestRepositories(t)
+
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signup", handlers.SignUpHandler(s)).Methods("POST")
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+	})
+
+	body := `{"email":"demo@example.com","password":"a-long-password"}`
+
+	if code := doBody(r, "POST", "/signup", "", body); code != http.StatusOK {
+		t.Fatalf("signup status = %v, want %v", code, http.StatusOK)
+	}
+
+	var response dto.SignInResponse
+
+	if code := decode(r, "POST", "/signin", "", body, &response); code != http.StatusOK || response.Token == "" {
+		t.Fatalf("signin status = %v, response = %+v", code, response)
+	}
+
+	if code := doBody(r, "POST", "/signup", "", body); code == http.StatusOK {
+		t.Error("signed up twice with the same email")
+	}
+}
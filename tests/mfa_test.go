@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/totp"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to 6 digits.
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := totp.Code(secret, totp.Step(time.Unix(tt.unix, 0)))
+
+		if err != nil || got != tt.want {
+			t.Errorf("Code() at %v = %v (%v), want %v", tt.unix, got, err, tt.want)
+		}
+	}
+
+	if _, ok := totp.Validate(secret, "287082", time.Unix(59+30, 0)); !ok {
+		t.Error("Validate() rejected the code of the previous step")
+	}
+
+	if _, ok := totp.Validate(secret, "287082", time.Unix(59+90, 0)); ok {
+		t.Error("Validate() accepted a code three steps old")
+	}
+}
+
+// decode runs the request and decodes the JSON response into v.
+func decode(r http.Handler, method string, path string, token string, body string, v any) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	json.NewDecoder(rec.Body).Decode(v)
+
+	return rec.Code
+}
+
+func TestMFASignIn(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+		r.HandleFunc("/signin/mfa", handlers.MFASignInHandler(s)).Methods("POST")
+		r.HandleFunc("/users/me/mfa", handlers.EnrollMFAHandler(s)).Methods("POST")
+		r.HandleFunc("/users/me/mfa/confirm", handlers.ConfirmMFAHandler(s)).Methods("POST")
+	})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("admin-password"), bcrypt.MinCost)
+	newTestRepositories(t)
+	admin := &models.User{Email: "admin@example.com", Password: string(hashedPassword), Role: models.AdminRole}
+	repositories.CreateUser(context.Background(), admin)
+	t.Cleanup(func() {
+		lockout.Reset(context.Background(), lockout.MFA(admin.Id), lockout.IP("192.0.2.1"))
+	})
+
+	token := signToken(t, admin.Id, time.Hour)
+
+	var enrollment dto.MFAEnrollResponse
+
+	if code := decode(r, "POST", "/users/me/mfa", token, "", &enrollment); code != http.StatusOK {
+		t.Fatalf("enroll status = %v, want %v", code, http.StatusOK)
+	}
+
+	if !strings.HasPrefix(enrollment.URI, "otpauth://totp/") || !strings.Contains(enrollment.URI, "secret="+enrollment.Secret) {
+		t.Errorf("otpauth URI = %v", enrollment.URI)
+	}
+
+	step := totp.Step(time.Now())
+	current, _ := totp.Code(enrollment.Secret, step)
+	stale, _ := totp.Code(enrollment.Secret, step-5)
+
+	if code := doBody(r, "POST", "/users/me/mfa/confirm", token, `{"code":"`+stale+`"}`); code != http.StatusBadRequest {
+		t.Errorf("confirm with a stale code status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	var confirmation dto.MFAConfirmResponse
+
+	if code := decode(r, "POST", "/users/me/mfa/confirm", token, `{"code":"`+current+`"}`, &confirmation); code != http.StatusOK {
+		t.Fatalf("confirm status = %v, want %v", code, http.StatusOK)
+	}
+
+	if len(confirmation.RecoveryCodes) != handlers.RECOVERY_CODES {
+		t.Fatalf("got %d recovery codes, want %d", len(confirmation.RecoveryCodes), handlers.RECOVERY_CODES)
+	}
+
+	var challenge dto.MFAChallengeResponse
+
+	if code := decode(r, "POST", "/signin", "", `{"email":"admin@example.com","password":"admin-password"}`, &challenge); code != http.StatusOK {
+		t.Fatalf("signin status = %v, want %v", code, http.StatusOK)
+	}
+
+	if !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("signin response = %+v, want an MFA challenge", challenge)
+	}
+
+	if code := do(r, "GET", "/protected", challenge.MFAToken); code != http.StatusUnauthorized {
+		t.Errorf("challenge used as access token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	signIn := func(code string) (int, *dto.SignInResponse) {
+		var response dto.SignInResponse
+		status := decode(r, "POST", "/signin/mfa", "", `{"mfa_token":"`+challenge.MFAToken+`","code":"`+code+`"}`, &response)
+
+		return status, &response
+	}
+
+	if code, _ := signIn(current); code != http.StatusUnauthorized {
+		t.Errorf("reused TOTP code status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	next, _ := totp.Code(enrollment.Secret, step+1)
+
+	if code, pair := signIn(next); code != http.StatusOK || pair.Token == "" {
+		t.Errorf("TOTP code status = %v, want %v with tokens", code, http.StatusOK)
+	}
+
+	recoveryCode := strings.ToUpper(confirmation.RecoveryCodes[0])
+
+	if code, pair := signIn(recoveryCode); code != http.StatusOK || pair.Token == "" {
+		t.Errorf("recovery code status = %v, want %v with tokens", code, http.StatusOK)
+	}
+
+	if code, _ := signIn(recoveryCode); code != http.StatusUnauthorized {
+		t.Errorf("reused recovery code status = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"tincho.dev/rest-ws/database"
+	"tincho.dev/rest-ws/migrate"
+	"tincho.dev/rest-ws/models"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX")},
+		"0002_add_index.down.sql":    {Data: []byte("DROP INDEX")},
+		"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE")},
+		"0001_create_users.down.sql": {Data: []byte("DROP TABLE")},
+		"README.md":                  {Data: []byte("ignored")},
+		"0010_create_posts.up.sql":   {Data: []byte("CREATE TABLE posts")},
+		"0010_create_posts.down.sql": {Data: []byte("DROP TABLE posts")},
+	}
+
+	migrations, err := migrate.Load(fsys)
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var versions []int64
+
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 10 {
+		t.Fatalf("Load() versions = %v, want [1 2 10]", versions)
+	}
+
+	if migrations[0].Name != "create_users" || migrations[0].Up != "CREATE TABLE" || migrations[0].Down != "DROP TABLE" {
+		t.Errorf("Load() first migration = %+v", migrations[0])
+	}
+
+	delete(fsys, "0002_add_index.down.sql")
+
+	if _, err := migrate.Load(fsys); err == nil {
+		t.Error("Load() accepted a migration without a down file")
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := migrate.Create(dir, "create_users"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	files, err := migrate.Create(dir, "add_bio")
+
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "0002_add_bio.up.sql"), filepath.Join(dir, "0002_add_bio.down.sql")}
+
+	if len(files) != 2 || files[0] != want[0] || files[1] != want[1] {
+		t.Errorf("Create() = %v, want %v", files, want)
+	}
+
+	if _, err := os.Stat(want[1]); err != nil {
+		t.Errorf("Stat() error = %v", err)
+	}
+
+	if _, err := migrate.Create(dir, "drop users;"); err == nil {
+		t.Error("Create() accepted an invalid name")
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	migrations, err := migrate.Load(database.PostgresMigrations())
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s, want version %d", m.Version, m.Name, i+1)
+		}
+	}
+}
+
+// baselineSchema is the init.sql the databases were created with before the
+// migrations.
+const baselineSchema = `
+CREATE TABLE "users" (
+  "id" SERIAL PRIMARY KEY,
+  "email" VARCHAR(255) NOT NULL UNIQUE,
+  "password" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE "posts" (
+  "id" SERIAL PRIMARY KEY,
+  "title" VARCHAR(255) NOT NULL,
+  "content" TEXT NOT NULL,
+  "user_id" INT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  "updated_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+`
+
+// TestPostgresMigrationsAdoptInitSQL needs a database in TEST_DATABASE_URL,
+// whose tables are dropped.
+func TestPostgresMigrationsAdoptInitSQL(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL isn't set")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", databaseURL)
+
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	for _, query := range []string{
+		"DROP SCHEMA public CASCADE",
+		"CREATE SCHEMA public",
+		baselineSchema,
+		`INSERT INTO users (email, password) VALUES ('old@example.com', 'hash')`,
+	} {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("Exec(%q) error = %v", query, err)
+		}
+	}
+
+	repo, err := database.NewPostgres(databaseURL)
+
+	if err != nil {
+		t.Fatalf("NewPostgres() error = %v", err)
+	}
+
+	defer repo.Close()
+
+	migrator, err := repo.Migrator()
+
+	if err != nil {
+		t.Fatalf("Migrator() error = %v", err)
+	}
+
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	user, err := repo.GetUserByEmail(ctx, "old@example.com")
+
+	if err != nil || user.Role != models.UserRole || user.EmailVerifiedAt != nil {
+		t.Errorf("GetUserByEmail() = %+v, %v, want the former user with the %q role", user, err, models.UserRole)
+	}
+}
@@ -0,0 +1,321 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/oidc"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/signing"
+)
+
+const oidcClientId = "rest-ws"
+
+// mockProvider is a minimal OpenID Connect provider. Its authorization
+// endpoint signs in the user set in claims right away.
+type mockProvider struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	keys      *signing.KeySet
+	claims    jwt.MapClaims
+	codes     map[string]jwt.MapClaims
+	challenge string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	keys, err := signing.LoadKeySet([]string{writeKey(t, t.TempDir(), "provider", key)})
+
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+
+	p := &mockProvider{keys: keys, codes: make(map[string]jwt.MapClaims)}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	r.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(p.keys.JWKS())
+	})
+	r.HandleFunc("/authorize", p.authorize)
+	r.HandleFunc("/token", p.token).Methods("POST")
+
+	p.Server = httptest.NewServer(r)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	query := r.URL.Query()
+
+	if query.Get("client_id") != oidcClientId || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   oidcClientId,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+
+	code := "code-" + query.Get("state")
+	p.codes[code] = claims
+	p.challenge = query.Get("code_challenge")
+
+	http.Redirect(w, r, query.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	clientId, secret, ok := r.BasicAuth()
+	claims, found := p.codes[r.PostFormValue("code")]
+
+	if !ok || clientId != oidcClientId || secret != "client-secret" || !found {
+		http.Error(w, "invalid grant", http.StatusBadRequest)
+		return
+	}
+
+	if oidc.Challenge(r.PostFormValue("code_verifier")) != p.challenge {
+		http.Error(w, "invalid code verifier", http.StatusBadRequest)
+		return
+	}
+
+	delete(p.codes, r.PostFormValue("code"))
+
+	idToken, err := p.keys.Sign(claims)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func newOIDCRouter(t *testing.T, p *mockProvider) *mux.Router {
+	t.Helper()
+
+	newTestRepositories(t)
+
+	s, err := server.NewServer(context.Background(), &server.Config{
+		Port:             ":0",
+		JWTSecret:        testSecret,
+		DatabaseURL:      "postgres://localhost/test",
+		AppURL:           "http://app.test",
+		OIDCIssuerURL:    p.URL,
+		OIDCClientID:     oidcClientId,
+		OIDCClientSecret: "client-secret",
+	})
+
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	return newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/auth/oidc/login", handlers.OIDCLoginHandler(s)).Methods("GET")
+		r.HandleFunc("/auth/oidc/callback", handlers.OIDCCallbackHandler(s)).Methods("GET")
+	})
+}
+
+// oidcSignIn goes through the login redirect and the provider, and returns
+// the callback request the browser would send.
+func oidcSignIn(t *testing.T, r http.Handler) *http.Request {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %v, want %v", rec.Code, http.StatusFound)
+	}
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(rec.Header().Get("Location"))
+
+	if err != nil {
+		t.Fatalf("authorize error = %v", err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %v, want %v", res.StatusCode, http.StatusFound)
+	}
+
+	req := httptest.NewRequest("GET", res.Header.Get("Location"), nil)
+
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+
+	return req
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	p := newMockProvider(t)
+	r := newOIDCRouter(t, p)
+
+	p.claims = jwt.MapClaims{"sub": "sso-1", "email": "sso@example.com", "email_verified": true}
+
+	var first dto.SignInResponse
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, oidcSignIn(t, r))
+	json.NewDecoder(rec.Body).Decode(&first)
+
+	if rec.Code != http.StatusOK || first.Token == "" || first.RefreshToken == "" {
+		t.Fatalf("callback status = %v, response = %+v", rec.Code, first)
+	}
+
+	user, err := repositories.GetUserByEmail(context.Background(), "sso@example.com")
+
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+
+	if user.EmailVerifiedAt == nil {
+		t.Error("the provider verified the email, but the user isn't verified")
+	}
+
+	// The second time the user is found through the linked identity, even if
+	// the email changed at the provider.
+	p.claims["email"] = "renamed@example.com"
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, oidcSignIn(t, r))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second callback status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	claims, err := parseTestToken(first.Token)
+
+	if err != nil || claims.UserId != user.Id {
+		t.Errorf("token user = %v (%v), want %v", claims.UserId, err, user.Id)
+	}
+
+	if _, err := repositories.GetUserByEmail(context.Background(), "renamed@example.com"); err == nil {
+		t.Error("a second user was created for the same identity")
+	}
+}
+
+func TestOIDCSignInUnverifiedEmailTaken(t *testing.T) {
+	p := newMockProvider(t)
+	r := newOIDCRouter(t, p)
+
+	user := &models.User{Email: "taken@example.com", Password: "hash"}
+	repositories.CreateUser(context.Background(), user)
+
+	p.claims = jwt.MapClaims{"sub": "sso-2", "email": "taken@example.com", "email_verified": false}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, oidcSignIn(t, r))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("callback status = %v, want %v", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestOIDCCallbackRejected(t *testing.T) {
+	p := newMockProvider(t)
+	r := newOIDCRouter(t, p)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		tamper func(req *http.Request)
+	}{
+		{
+			name:   "state mismatch",
+			claims: jwt.MapClaims{"sub": "sso-3"},
+			tamper: func(req *http.Request) {
+				query := req.URL.Query()
+				query.Set("state", "forged")
+				req.URL.RawQuery = query.Encode()
+			},
+		},
+		{
+			name:   "missing state cookie",
+			claims: jwt.MapClaims{"sub": "sso-3"},
+			tamper: func(req *http.Request) { req.Header.Del("Cookie") },
+		},
+		{
+			name:   "wrong nonce",
+			claims: jwt.MapClaims{"sub": "sso-3", "nonce": "replayed"},
+		},
+		{
+			name:   "wrong audience",
+			claims: jwt.MapClaims{"sub": "sso-3", "aud": "another-client"},
+		},
+		{
+			name:   "wrong issuer",
+			claims: jwt.MapClaims{"sub": "sso-3", "iss": "https://evil.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.claims = tt.claims
+			req := oidcSignIn(t, r)
+
+			if tt.tamper != nil {
+				tt.tamper(req)
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("callback status = %v, want %v", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/utils"
+)
+
+var resetTokenPattern = regexp.MustCompile(`(?m)^[A-Za-z0-9_-]{43}$`)
+
+// waitForMail returns the last email sent to the address.
+func waitForMail(t *testing.T, email string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for time.Now().Before(deadline) {
+		messages := strings.Split(mailbox.String(), "---\n")
+
+		for i := len(messages) - 1; i >= 0; i-- {
+			if strings.HasPrefix(messages[i], "To: "+email+"\n") {
+				return messages[i]
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("no email sent to %v", email)
+
+	return ""
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/password/forgot", handlers.ForgotPasswordHandler(s)).Methods("POST")
+		r.HandleFunc("/password/reset", handlers.ResetPasswordHandler(s)).Methods("POST")
+	})
+
+	mailbox.reset()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	newTestRepositories(t)
+	forgetful := &models.User{Email: "forgetful@example.com", Password: string(hashedPassword)}
+	repositories.CreateUser(context.Background(), forgetful)
+	repositories.CreateSession(context.Background(), &models.Session{
+		ID:        "forgotten",
+		UserID:    forgetful.Id,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	token := signSessionToken(t, forgetful.Id, "forgotten", time.Hour)
+	key := models.APIKeyPrefix + "forgetful"
+	repositories.CreateAPIKey(context.Background(), &models.APIKey{UserID: forgetful.Id, Name: "ci", KeyHash: utils.HashToken(key), Scopes: []string{models.ReadScope}})
+
+	if code := doBody(r, "POST", "/password/forgot", "", `{"email":"nobody@example.com"}`); code != http.StatusOK {
+		t.Errorf("unknown email status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := doBody(r, "POST", "/password/forgot", "", `{"email":"forgetful@example.com"}`); code != http.StatusOK {
+		t.Fatalf("forgot status = %v, want %v", code, http.StatusOK)
+	}
+
+	resetToken := resetTokenPattern.FindString(waitForMail(t, "forgetful@example.com"))
+
+	if resetToken == "" {
+		t.Fatal("the email doesn't contain a reset token")
+	}
+
+	if code := doBody(r, "POST", "/password/reset", "", `{"token":"wrong","password":"new-password"}`); code != http.StatusBadRequest {
+		t.Errorf("wrong token status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	body := `{"token":"` + resetToken + `","password":"new-password"}`
+
+	if code := doBody(r, "POST", "/password/reset", "", body); code != http.StatusOK {
+		t.Fatalf("reset status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := doBody(r, "POST", "/password/reset", "", body); code != http.StatusBadRequest {
+		t.Errorf("reused token status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	user, _ := repositories.FindUserById(context.Background(), forgetful.Id)
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")) != nil {
+		t.Error("the password wasn't updated")
+	}
+
+	if code := do(r, "GET", "/protected", token); code != http.StatusUnauthorized {
+		t.Errorf("token issued before the reset status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", key); code != http.StatusUnauthorized {
+		t.Errorf("API key status after the reset = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	s, err := server.NewServer(context.Background(), &server.Config{
+		Port:           ":0",
+		JWTSecret:      testSecret,
+		DatabaseURL:    "postgres://localhost/test",
+		PasswordPolicy: utils.PasswordPolicy{MinLength: 10, RequireDigit: true},
+	})
+
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/me/password", handlers.ChangePasswordHandler(s)).Methods("PUT")
+	})
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	newTestRepositories(t)
+	careful := &models.User{Email: "careful@example.com", Password: string(hashedPassword)}
+	repositories.CreateUser(context.Background(), careful)
+
+	for _, id := range []string{"desk", "tablet"} {
+		repositories.CreateSession(context.Background(), &models.Session{
+			ID:        id,
+			UserID:    careful.Id,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+	}
+
+	desk := signSessionToken(t, careful.Id, "desk", time.Hour)
+	tablet := signSessionToken(t, careful.Id, "tablet", time.Hour)
+	key := models.APIKeyPrefix + "careful"
+	repositories.CreateAPIKey(context.Background(), &models.APIKey{UserID: careful.Id, Name: "ci", KeyHash: utils.HashToken(key), Scopes: []string{models.ReadScope}})
+
+	if code := doBody(r, "PUT", "/users/me/password", desk, `{"current_password":"wrong-password","new_password":"new-password-1"}`); code != http.StatusForbidden {
+		t.Errorf("wrong current password status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	if code := doBody(r, "PUT", "/users/me/password", desk, `{"current_password":"old-password","new_password":"new-password"}`); code != http.StatusBadRequest {
+		t.Errorf("weak password status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	if code := doBody(r, "PUT", "/users/me/password", desk, `{"current_password":"old-password","new_password":"new-password-1"}`); code != http.StatusOK {
+		t.Fatalf("change password status = %v, want %v", code, http.StatusOK)
+	}
+
+	user, _ := repositories.FindUserById(context.Background(), careful.Id)
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password-1")) != nil {
+		t.Error("the password wasn't updated")
+	}
+
+	if code := do(r, "GET", "/protected", desk); code != http.StatusOK {
+		t.Errorf("current session status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "GET", "/protected", tablet); code != http.StatusUnauthorized {
+		t.Errorf("other session status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code := doAPIKey(r, "GET", "/protected", key); code != http.StatusOK {
+		t.Errorf("API key status after the change = %v, want %v", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/pubsub"
+)
+
+func TestInProcessPubSubFanOut(t *testing.T) {
+	ps := pubsub.NewInProcess()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, _ := ps.Subscribe(ctx)
+	second, _ := ps.Subscribe(ctx)
+
+	err := ps.Publish(ctx, &models.Notification{
+		Topics: []string{models.PostsTopic},
+		Event:  models.Event{Type: models.PostCreatedEvent},
+	})
+
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for _, subscriber := range []<-chan *models.Notification{first, second} {
+		select {
+		case n := <-subscriber:
+			if n.Event.Type != models.PostCreatedEvent {
+				t.Errorf("event type = %v, want %v", n.Event.Type, models.PostCreatedEvent)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber didn't receive the notification")
+		}
+	}
+
+	cancel()
+
+	if _, ok := <-first; ok {
+		t.Errorf("subscriber channel should be closed once its context is done")
+	}
+}
+
+func TestInProcessPubSubSkipsSlowSubscriber(t *testing.T) {
+	ps := pubsub.NewInProcess()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow, _ := ps.Subscribe(ctx)
+	fast, _ := ps.Subscribe(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		// One more than the buffer of the slow subscriber, which never reads.
+		for i := 0; i <= 256; i++ {
+			ps.Publish(ctx, &models.Notification{Event: models.Event{Type: models.PostCreatedEvent}})
+			<-fast
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish() blocked on a slow subscriber")
+	}
+
+	if len(slow) != 256 {
+		t.Errorf("slow subscriber has %d notifications, want 256", len(slow))
+	}
+}
+
+func TestDispatchDeliversNotifications(t *testing.T) {
+	s := newTestServer(t)
+	srv := newWebSocketServer(t, s)
+
+	ps := pubsub.NewInProcess()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifications, _ := ps.Subscribe(ctx)
+	go s.Dispatch(notifications)
+
+	subscriber := dialHub(t, srv.URL, signToken(t, 1, time.Hour))
+	subscribe(t, subscriber, models.PostsTopic)
+
+	recipient := dialHub(t, srv.URL, signToken(t, 2, time.Hour))
+	waitRegistered(t, recipient)
+
+	ps.Publish(ctx, &models.Notification{
+		Topics: []string{models.PostsTopic},
+		Event:  models.Event{Type: models.PostCreatedEvent},
+	})
+
+	ps.Publish(ctx, &models.Notification{
+		Recipients: []int64{2},
+		Event:      models.Event{Type: models.MessageCreatedEvent},
+	})
+
+	if event := readEvent(t, subscriber); event.Type != models.PostCreatedEvent {
+		t.Errorf("subscriber event type = %v, want %v", event.Type, models.PostCreatedEvent)
+	}
+
+	if event := readEvent(t, recipient); event.Type != models.MessageCreatedEvent {
+		t.Errorf("recipient event type = %v, want %v", event.Type, models.MessageCreatedEvent)
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"tincho.dev/rest-ws/database"
+	"tincho.dev/rest-ws/memory"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/repositories/repotest"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	t.Run("users", func(t *testing.T) {
+		repotest.RunUserRepositorySuite(t, func(t *testing.T) repositories.UserRepository {
+			return memory.NewMemory()
+		})
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		repotest.RunPostRepositorySuite(t, func(t *testing.T) repotest.PostRepository {
+			return memory.NewMemory()
+		})
+	})
+}
+
+func TestSQLiteRepositories(t *testing.T) {
+	t.Run("users", func(t *testing.T) {
+		repotest.RunUserRepositorySuite(t, func(t *testing.T) repositories.UserRepository {
+			return newTestSQLite(t)
+		})
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		repotest.RunPostRepositorySuite(t, func(t *testing.T) repotest.PostRepository {
+			return newTestSQLite(t)
+		})
+	})
+}
+
+// TestPostgresRepositories needs a database in TEST_DATABASE_URL. Its tables
+// are emptied before every test.
+func TestPostgresRepositories(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL isn't set")
+	}
+
+	t.Run("users", func(t *testing.T) {
+		repotest.RunUserRepositorySuite(t, func(t *testing.T) repositories.UserRepository {
+			return newTestPostgres(t, databaseURL)
+		})
+	})
+
+	t.Run("posts", func(t *testing.T) {
+		repotest.RunPostRepositorySuite(t, func(t *testing.T) repotest.PostRepository {
+			return newTestPostgres(t, databaseURL)
+		})
+	})
+}
+
+// newTestPostgres migrates the database and deletes every user, which
+// deletes everything else with them.
+func newTestPostgres(t *testing.T, databaseURL string) *database.Postgres {
+	t.Helper()
+
+	repo, err := database.NewPostgres(databaseURL)
+
+	if err != nil {
+		t.Fatalf("NewPostgres() error = %v", err)
+	}
+
+	t.Cleanup(func() { repo.Close() })
+
+	migrator, err := repo.Migrator()
+
+	if err != nil {
+		t.Fatalf("Migrator() error = %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec("TRUNCATE users RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatalf("TRUNCATE error = %v", err)
+	}
+
+	return repo
+}
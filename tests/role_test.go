@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/middlewares"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+)
+
+func newRoleRouter(s server.Server) *mux.Router {
+	return newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/{id}", handlers.DeleteUserHandler(s)).Methods("DELETE")
+		r.Handle("/users/{id}/role", middlewares.RequireRole(models.AdminRole)(handlers.UpdateUserRoleHandler(s))).Methods("PUT")
+		r.HandleFunc("/posts/{id}", handlers.UpdateOnePostHandler(s)).Methods("PUT")
+		r.HandleFunc("/posts/{id}", handlers.DeleteOnePostHandler(s)).Methods("DELETE")
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	s := newTestServer(t)
+	r := newRoleRouter(s)
+
+	newTestRepositories(t)
+	promoted := &models.User{Email: "promoted@example.com"}
+	repositories.CreateUser(context.Background(), promoted)
+	path := fmt.Sprintf("/users/%d/role", promoted.Id)
+
+	for role, want := range map[string]int{
+		"":                   http.StatusForbidden,
+		models.UserRole:      http.StatusForbidden,
+		models.ModeratorRole: http.StatusForbidden,
+		models.AdminRole:     http.StatusOK,
+	} {
+		if code := doBody(r, "PUT", path, signRoleToken(t, promoted.Id+1, role), `{"role":"moderator"}`); code != want {
+			t.Errorf("role %q status = %v, want %v", role, code, want)
+		}
+	}
+
+	user, _ := repositories.FindUserById(context.Background(), promoted.Id)
+
+	if user.Role != models.ModeratorRole {
+		t.Errorf("role = %v, want %v", user.Role, models.ModeratorRole)
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	s := newTestServer(t)
+	r := newRoleRouter(s)
+
+	newTestRepositories(t)
+	author := &models.User{Email: "author@example.com"}
+	deleted := &models.User{Email: "deleted@example.com"}
+	repositories.CreateUser(context.Background(), author)
+	repositories.CreateUser(context.Background(), deleted)
+
+	first := &models.Post{UserID: author.Id, Title: "Title", Content: "Content"}
+	second := &models.Post{UserID: author.Id, Title: "Title", Content: "Content"}
+	repositories.CreatePost(context.Background(), first)
+	repositories.CreatePost(context.Background(), second)
+
+	user := signRoleToken(t, deleted.Id+1, models.UserRole)
+	moderator := signRoleToken(t, deleted.Id+2, models.ModeratorRole)
+	admin := signRoleToken(t, deleted.Id+3, models.AdminRole)
+	firstPath := fmt.Sprintf("/posts/%d", first.ID)
+	userPath := fmt.Sprintf("/users/%d", deleted.Id)
+	update := `{"title":"Edited","content":"Edited"}`
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"user can't update others' posts", "PUT", firstPath, user, http.StatusForbidden},
+		{"moderator can't update others' posts", "PUT", firstPath, moderator, http.StatusForbidden},
+		{"admin can update any post", "PUT", firstPath, admin, http.StatusOK},
+		{"user can't delete others' posts", "DELETE", firstPath, user, http.StatusForbidden},
+		{"moderator can delete any post", "DELETE", firstPath, moderator, http.StatusOK},
+		{"admin can delete any post", "DELETE", fmt.Sprintf("/posts/%d", second.ID), admin, http.StatusOK},
+		{"user can't delete other users", "DELETE", userPath, user, http.StatusForbidden},
+		{"admin can delete any user", "DELETE", userPath, admin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code := doBody(r, tt.method, tt.path, tt.token, update); code != tt.want {
+			t.Errorf("%s: status = %v, want %v", tt.name, code, tt.want)
+		}
+	}
+}
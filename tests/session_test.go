@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+)
+
+func TestSessionsListAndDelete(t *testing.T) {
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/users/me/sessions", handlers.ListSessionsHandler(s)).Methods("GET")
+		r.HandleFunc("/users/me/sessions/{id}", handlers.DeleteSessionHandler(s)).Methods("DELETE")
+	})
+
+	newTestRepositories(t)
+	user := &models.User{Email: "user@example.com"}
+	other := &models.User{Email: "other@example.com"}
+	repositories.CreateUser(context.Background(), user)
+	repositories.CreateUser(context.Background(), other)
+
+	for _, session := range []*models.Session{
+		{ID: "laptop", UserID: user.Id, UserAgent: "Firefox", IP: "10.0.0.1"},
+		{ID: "phone", UserID: user.Id, UserAgent: "Safari", IP: "10.0.0.2"},
+		{ID: "someone-else", UserID: other.Id, UserAgent: "Chrome", IP: "10.0.0.3"},
+	} {
+		session.ExpiresAt = time.Now().Add(time.Hour)
+		repositories.CreateSession(context.Background(), session)
+	}
+
+	laptop := signSessionToken(t, user.Id, "laptop", time.Hour)
+	phone := signSessionToken(t, user.Id, "phone", time.Hour)
+
+	req := httptest.NewRequest("GET", "/users/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+laptop)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var sessions []dto.SessionResponse
+	json.NewDecoder(rec.Body).Decode(&sessions)
+
+	if len(sessions) != 2 {
+		t.Fatalf("listed %d sessions, want 2", len(sessions))
+	}
+
+	for _, session := range sessions {
+		if session.Current != (session.Id == "laptop") {
+			t.Errorf("session %v current = %v", session.Id, session.Current)
+		}
+	}
+
+	if code := do(r, "DELETE", "/users/me/sessions/someone-else", laptop); code != http.StatusNotFound {
+		t.Errorf("deleting another user's session status = %v, want %v", code, http.StatusNotFound)
+	}
+
+	if code := do(r, "DELETE", "/users/me/sessions/phone", laptop); code != http.StatusOK {
+		t.Fatalf("delete status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "GET", "/protected", phone); code != http.StatusUnauthorized {
+		t.Errorf("deleted session token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code := do(r, "GET", "/protected", laptop); code != http.StatusOK {
+		t.Errorf("remaining session token status = %v, want %v", code, http.StatusOK)
+	}
+}
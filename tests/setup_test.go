@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"tincho.dev/rest-ws/lockout"
+	"tincho.dev/rest-ws/mailer"
+	"tincho.dev/rest-ws/memory"
+	"tincho.dev/rest-ws/pubsub"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/revocation"
+)
+
+var mailbox = &mailboxBuffer{}
+
+func init() {
+	setRepositories(memory.NewMemory())
+	mailer.SetMailer(mailer.NewLog(mailbox))
+	pubsub.SetPubSub(pubsub.NewInProcess())
+	revocation.SetStore(revocation.NewInMemory())
+	lockout.SetStore(lockout.NewInMemory())
+}
+
+// storage is every repository, like the storage backends implement them.
+type storage interface {
+	repositories.UserRepository
+	repositories.PostRepository
+	repositories.MessageRepository
+	repositories.RefreshTokenRepository
+	repositories.SessionRepository
+	repositories.PasswordResetRepository
+	repositories.EmailVerificationRepository
+	repositories.MFARepository
+	repositories.APIKeyRepository
+	repositories.ExternalIdentityRepository
+}
+
+func setRepositories(s storage) {
+	repositories.SetUserRepository(s)
+	repositories.SetPostRepository(s)
+	repositories.SetMessageRepository(s)
+	repositories.SetRefreshTokenRepository(s)
+	repositories.SetSessionRepository(s)
+	repositories.SetPasswordResetRepository(s)
+	repositories.SetEmailVerificationRepository(s)
+	repositories.SetMFARepository(s)
+	repositories.SetAPIKeyRepository(s)
+	repositories.SetExternalIdentityRepository(s)
+}
+
+// newTestRepositories points the repositories to an empty memory storage,
+// so the test doesn't see the data of the others. The revocations are
+// cleared too, as the new users reuse the ids of the previous ones.
+func newTestRepositories(t *testing.T) *memory.Memory {
+	t.Helper()
+
+	m := memory.NewMemory()
+	setRepositories(m)
+	revocation.SetStore(revocation.NewInMemory())
+
+	return m
+}
+
+// mailboxBuffer collects the emails written by the log mailer, which are sent
+// from other goroutines.
+type mailboxBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *mailboxBuffer) reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.buffer.Reset()
+}
+
+func (b *mailboxBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *mailboxBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.String()
+}
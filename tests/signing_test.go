@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/server"
+	"tincho.dev/rest-ws/signing"
+)
+
+func writeKey(t *testing.T, dir string, name string, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	file := filepath.Join(dir, name+".pem")
+
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return file
+}
+
+func newKeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	return writeKey(t, dir, "current", edKey), writeKey(t, dir, "previous", rsaKey)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	current, previous := newKeyFiles(t)
+
+	oldKeys, err := signing.LoadKeySet([]string{previous})
+
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+
+	keys, err := signing.LoadKeySet([]string{current, previous})
+
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+
+	claims := &models.AppClaims{UserId: 1, StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+
+	newToken, _ := keys.Sign(claims)
+	oldToken, _ := oldKeys.Sign(claims)
+
+	for name, tokenString := range map[string]string{"new": newToken, "old": oldToken} {
+		token, err := jwt.ParseWithClaims(tokenString, &models.AppClaims{}, keys.Keyfunc)
+
+		if err != nil || !token.Valid {
+			t.Errorf("%s token should be valid during rotation, error = %v", name, err)
+		}
+	}
+
+	token, _ := jwt.Parse(newToken, keys.Keyfunc)
+
+	if token.Header["kid"] != "current" || token.Method.Alg() != "EdDSA" {
+		t.Errorf("token header = %v, want kid current signed with EdDSA", token.Header)
+	}
+}
+
+func TestKeySetRejectsAlgorithmConfusion(t *testing.T) {
+	current, previous := newKeyFiles(t)
+	keys, _ := signing.LoadKeySet([]string{current, previous})
+
+	// A token claiming to be HS256 must not be verified with a public key.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &models.AppClaims{UserId: 1})
+	token.Header["kid"] = "previous"
+	tokenString, _ := token.SignedString([]byte("anything"))
+
+	if _, err := jwt.Parse(tokenString, keys.Keyfunc); err == nil {
+		t.Errorf("HS256 token with an RSA kid should be rejected")
+	}
+
+	if _, err := jwt.Parse(signToken(t, 1, time.Hour), keys.Keyfunc); err == nil {
+		t.Errorf("HS256 token without kid should be rejected when keys are configured")
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	current, previous := newKeyFiles(t)
+
+	s, err := server.NewServer(context.Background(), &server.Config{
+		Port:        ":0",
+		JWTKeyFiles: []string{current, previous},
+		DatabaseURL: "postgres://localhost/test",
+	})
+
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handlers.JWKSHandler(s)(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	var jwks signing.JWKS
+	json.NewDecoder(rec.Body).Decode(&jwks)
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("published %d keys, want 2", len(jwks.Keys))
+	}
+
+	if jwks.Keys[0].Kid != "current" || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].X == "" {
+		t.Errorf("first key = %+v, want the Ed25519 key", jwks.Keys[0])
+	}
+
+	if jwks.Keys[1].Kid != "previous" || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].E != "AQAB" {
+		t.Errorf("second key = %+v, want the RSA key", jwks.Keys[1])
+	}
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/database/sqlite"
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/migrate"
+	"tincho.dev/rest-ws/models"
+)
+
+// newTestSQLite opens a migrated SQLite database in a temporary file.
+func newTestSQLite(t *testing.T) *sqlite.SQLite {
+	t.Helper()
+
+	repo, err := sqlite.NewSQLite("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+
+	t.Cleanup(func() { repo.Close() })
+
+	migrator, err := repo.Migrator()
+
+	if err != nil {
+		t.Fatalf("Migrator() error = %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	return repo
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLite(t)
+	migrator, _ := repo.Migrator()
+
+	migrations, err := migrate.Load(sqlite.Migrations())
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Every migration can be rolled back and applied again.
+	if reverted, err := migrator.Down(ctx, len(migrations)); err != nil || len(reverted) != len(migrations) {
+		t.Fatalf("Down() = %d migrations, %v, want %d", len(reverted), err, len(migrations))
+	}
+
+	if applied, err := migrator.Up(ctx, 0); err != nil || len(applied) != len(migrations) {
+		t.Fatalf("Up() = %d migrations, %v, want %d", len(applied), err, len(migrations))
+	}
+}
+
+func TestSQLiteDeleteUserCascades(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLite(t)
+
+	author := &models.User{Email: "author@example.com", Password: "hash"}
+
+	if err := repo.CreateUser(ctx, author); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if err := repo.CreateUser(ctx, &models.User{Email: "author@example.com", Password: "hash"}); err == nil {
+		t.Error("CreateUser() accepted a duplicated email")
+	}
+
+	post := &models.Post{Title: "Title", Content: "Content", UserID: author.Id}
+
+	if err := repo.CreatePost(ctx, post); err != nil {
+		t.Fatalf("CreatePost() error = %v", err)
+	}
+
+	user, err := repo.FindUserById(ctx, author.Id)
+
+	if err != nil || len(user.Posts) != 1 {
+		t.Fatalf("FindUserById() = %+v, %v, want 1 post", user, err)
+	}
+
+	if err := repo.DeleteOneUser(ctx, author.Id); err != nil {
+		t.Fatalf("DeleteOneUser() error = %v", err)
+	}
+
+	if _, err := repo.FindPostById(ctx, post.ID); err == nil {
+		t.Error("the posts of the deleted user are still there")
+	}
+}
+
+func TestSQLiteSessionsAndAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLite(t)
+
+	user := &models.User{Email: "user@example.com", Password: "hash"}
+	repo.CreateUser(ctx, user)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	session := &models.Session{ID: "session-1", UserID: user.Id, UserAgent: "test", IP: "127.0.0.1", ExpiresAt: expiresAt}
+
+	if err := repo.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	found, err := repo.FindSessionById(ctx, session.ID)
+
+	if err != nil || !found.ExpiresAt.Equal(expiresAt) || found.CreatedAt.IsZero() {
+		t.Fatalf("FindSessionById() = %+v, %v, want expiration %v", found, err, expiresAt)
+	}
+
+	if sessions, err := repo.ListActiveSessions(ctx, user.Id); err != nil || len(sessions) != 1 {
+		t.Errorf("ListActiveSessions() = %d sessions, %v, want 1", len(sessions), err)
+	}
+
+	repo.RevokeSession(ctx, session.ID)
+
+	if sessions, _ := repo.ListActiveSessions(ctx, user.Id); len(sessions) != 0 {
+		t.Errorf("ListActiveSessions() = %d sessions after revoking, want 0", len(sessions))
+	}
+
+	key := &models.APIKey{UserID: user.Id, Name: "ci", Prefix: "rws_abc", KeyHash: "hash", Scopes: []string{models.ReadScope}}
+
+	if err := repo.CreateAPIKey(ctx, key); err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	foundKey, err := repo.FindAPIKeyByHash(ctx, "hash")
+
+	if err != nil || len(foundKey.Scopes) != 1 || foundKey.Scopes[0] != models.ReadScope || foundKey.ExpiresAt != nil {
+		t.Errorf("FindAPIKeyByHash() = %+v, %v", foundKey, err)
+	}
+}
+
+// TestSQLiteSignIn runs the sign up and sign in handlers against SQLite, as
+// the server does with a sqlite:// DATABASE_URL.
+func TestSQLiteSignIn(t *testing.T) {
+	repo := newTestSQLite(t)
+	setRepositories(repo)
+	t.Cleanup(func() { newTestRepositories(t) })
+
+	s := newTestServer(t)
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signup", handlers.SignUpHandler(s)).Methods("POST")
+		r.HandleFunc("/signin", handlers.SignInHandler(s)).Methods("POST")
+	})
+
+	body := `{"email":"demo@example.com","password":"a-long-password"}`
+
+	if code := doBody(r, "POST", "/signup", "", body); code != http.StatusOK {
+		t.Fatalf("signup status = %v, want %v", code, http.StatusOK)
+	}
+
+	var response dto.SignInResponse
+
+	if code := decode(r, "POST", "/signin", "", body, &response); code != http.StatusOK || response.Token == "" {
+		t.Fatalf("signin status = %v, response = %+v", code, response)
+	}
+}
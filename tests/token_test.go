@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tincho.dev/rest-ws/dto"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/utils"
+)
+
+func refresh(t *testing.T, h http.HandlerFunc, refreshToken string) (int, *dto.SignInResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/token/refresh", strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	var response dto.SignInResponse
+	json.NewDecoder(rec.Body).Decode(&response)
+
+	return rec.Code, &response
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	h := handlers.RefreshTokenHandler(s)
+
+	newTestRepositories(t)
+	user := &models.User{Email: "rotation@example.com", Role: models.ModeratorRole}
+	repositories.CreateUser(context.Background(), user)
+
+	repositories.CreateRefreshToken(context.Background(), &models.RefreshToken{
+		UserID:    user.Id,
+		FamilyID:  "rotation",
+		TokenHash: utils.HashToken("first"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	code, pair := refresh(t, h, "first")
+
+	if code != http.StatusOK || pair.Token == "" || pair.RefreshToken == "" {
+		t.Fatalf("refresh status = %v, want %v with a new token pair", code, http.StatusOK)
+	}
+
+	claims, err := parseTestToken(pair.Token)
+
+	if err != nil || claims.UserId != user.Id || claims.Role != models.ModeratorRole {
+		t.Errorf("access token claims = %+v (%v), want moderator user %d", claims, err, user.Id)
+	}
+
+	// Reusing the rotated token revokes the whole family, including the
+	// token that replaced it.
+	if code, _ := refresh(t, h, "first"); code != http.StatusUnauthorized {
+		t.Errorf("reused token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code, _ := refresh(t, h, pair.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("revoked family token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefreshTokenRejectsExpiredAndUnknown(t *testing.T) {
+	s := newTestServer(t)
+	h := handlers.RefreshTokenHandler(s)
+
+	newTestRepositories(t)
+	user := &models.User{Email: "expired@example.com"}
+	repositories.CreateUser(context.Background(), user)
+
+	repositories.CreateRefreshToken(context.Background(), &models.RefreshToken{
+		UserID:    user.Id,
+		FamilyID:  "expired",
+		TokenHash: utils.HashToken("expired"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+
+	if code, _ := refresh(t, h, "expired"); code != http.StatusUnauthorized {
+		t.Errorf("expired token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+
+	if code, _ := refresh(t, h, "unknown"); code != http.StatusUnauthorized {
+		t.Errorf("unknown token status = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
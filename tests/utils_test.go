@@ -26,6 +26,10 @@ func TestGetPagination(t *testing.T) {
 		{"offset=8", 8, 10, false},
 		// Caso con solo limit válido
 		{"limit=25", 0, 25, false},
+		// Caso con offset negativo
+		{"offset=-1&limit=2", 0, 0, true},
+		// Caso con limit negativo
+		{"offset=0&limit=-1", 0, 0, true},
 	}
 
 	for _, tt := range tests {
@@ -51,3 +55,30 @@ func TestGetPagination(t *testing.T) {
 		}
 	}
 }
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := utils.PasswordPolicy{MinLength: 10, RequireMixedCase: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"Short-1", true},
+		{"lower-case-1", true},
+		{"No-Digits-Here", true},
+		{"NoSymbols123", true},
+		{"Good-Password-1", false},
+	}
+
+	for _, tt := range tests {
+		err := policy.Check(tt.password)
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Check(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+
+	if err := (utils.PasswordPolicy{}).Check("anything"); err != nil {
+		t.Errorf("empty policy Check() error = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"tincho.dev/rest-ws/handlers"
+	"tincho.dev/rest-ws/repositories"
+	"tincho.dev/rest-ws/server"
+)
+
+var verificationLinkPattern = regexp.MustCompile(`/verify-email\?token=[A-Za-z0-9_-]{43}`)
+
+func TestEmailVerification(t *testing.T) {
+	s, err := server.NewServer(context.Background(), &server.Config{
+		Port:                 ":0",
+		JWTSecret:            testSecret,
+		DatabaseURL:          "postgres://localhost/test",
+		RequireVerifiedEmail: true,
+	})
+
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	r := newAuthRouter(s, func(r *mux.Router) {
+		r.HandleFunc("/signup", handlers.SignUpHandler(s)).Methods("POST")
+		r.HandleFunc("/verify-email", handlers.VerifyEmailHandler(s)).Methods("GET")
+		r.HandleFunc("/posts", handlers.CreatePostHandler(s)).Methods("POST")
+		r.HandleFunc("/users/{id}", handlers.UpdateUserHandler(s)).Methods("PUT")
+	})
+
+	newTestRepositories(t)
+	mailbox.reset()
+
+	if code := doBody(r, "POST", "/signup", "", `{"email":"new@example.com","password":"a-long-password"}`); code != http.StatusOK {
+		t.Fatalf("signup status = %v, want %v", code, http.StatusOK)
+	}
+
+	user, err := repositories.GetUserByEmail(context.Background(), "new@example.com")
+
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+
+	token := signToken(t, user.Id, time.Hour)
+	post := `{"title":"Hello","content":"World"}`
+
+	if code := doBody(r, "POST", "/posts", token, post); code != http.StatusForbidden {
+		t.Errorf("unverified create post status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	link := verificationLinkPattern.FindString(waitForMail(t, "new@example.com"))
+
+	if link == "" {
+		t.Fatal("the email doesn't contain a verification link")
+	}
+
+	if code := do(r, "GET", "/verify-email?token=wrong", ""); code != http.StatusBadRequest {
+		t.Errorf("wrong token status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	// The link sent to the former email doesn't verify the new one.
+	path := fmt.Sprintf("/users/%d", user.Id)
+
+	if code := doBody(r, "PUT", path, token, `{"email":"changed@example.com"}`); code != http.StatusOK {
+		t.Fatalf("change email status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "GET", link, ""); code != http.StatusBadRequest {
+		t.Errorf("link of the former email status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	if code := doBody(r, "POST", "/posts", token, post); code != http.StatusForbidden {
+		t.Errorf("create post after the former link status = %v, want %v", code, http.StatusForbidden)
+	}
+
+	link = verificationLinkPattern.FindString(waitForMail(t, "changed@example.com"))
+
+	if link == "" {
+		t.Fatal("the email doesn't contain a verification link")
+	}
+
+	if code := do(r, "GET", link, ""); code != http.StatusOK {
+		t.Fatalf("verify status = %v, want %v", code, http.StatusOK)
+	}
+
+	if code := do(r, "GET", link, ""); code != http.StatusBadRequest {
+		t.Errorf("reused link status = %v, want %v", code, http.StatusBadRequest)
+	}
+
+	if code := doBody(r, "POST", "/posts", token, post); code != http.StatusCreated {
+		t.Errorf("verified create post status = %v, want %v", code, http.StatusCreated)
+	}
+}
@@ -1,10 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 )
 
+// ErrInvalidPagination is returned for a negative offset or limit.
+var ErrInvalidPagination = errors.New("offset and limit must not be negative")
+
 func GetPagination(r *http.Request) (int64, int64, error) {
 	offset, limit := r.URL.Query().Get("offset"), r.URL.Query().Get("limit")
 
@@ -28,5 +32,9 @@ func GetPagination(r *http.Request) (int64, int64, error) {
 		return 0, 0, err
 	}
 
+	if offsetInt < 0 || limitInt < 0 {
+		return 0, 0, ErrInvalidPagination
+	}
+
 	return offsetInt, limitInt, nil
 }
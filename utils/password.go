@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+// PasswordPolicy lists the requirements a new password has to meet, on top
+// of the length checked by the request validation.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireMixedCase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// Check returns an error describing the first requirement the password
+// doesn't meet.
+func (p PasswordPolicy) Check(password string) error {
+	var upper, lower, digit, symbol bool
+
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			symbol = true
+		}
+	}
+
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+
+	if p.RequireMixedCase && (!upper || !lower) {
+		return errors.New("password must contain upper and lower case letters")
+	}
+
+	if p.RequireDigit && !digit {
+		return errors.New("password must contain a digit")
+	}
+
+	if p.RequireSymbol && !symbol {
+		return errors.New("password must contain a symbol")
+	}
+
+	return nil
+}
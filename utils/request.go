@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the address of the peer the request came from.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -9,7 +9,10 @@ import (
 )
 
 type StructConstraint interface {
-	dto.SignUpRequest | dto.SignInRequest | dto.CreatePostRequest | dto.UpdateOnePostRequest | dto.UpdateUserRequest
+	dto.SignUpRequest | dto.SignInRequest | dto.CreatePostRequest | dto.UpdateOnePostRequest | dto.UpdateUserRequest |
+		dto.SendMessageRequest | dto.RefreshTokenRequest | dto.UpdateUserRoleRequest |
+		dto.ForgotPasswordRequest | dto.ResetPasswordRequest | dto.ChangePasswordRequest |
+		dto.MFACodeRequest | dto.MFASignInRequest | dto.CreateAPIKeyRequest
 }
 
 func Validate[T StructConstraint](r *http.Request) (*T, error) {
@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"tincho.dev/rest-ws/models"
+	"tincho.dev/rest-ws/revocation"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 512
+)
+
+const (
+	SubscribeAction   = "subscribe"
+	UnsubscribeAction = "unsubscribe"
+)
+
+const (
+	SubscribedMessageType   = "subscribed"
+	UnsubscribedMessageType = "unsubscribed"
+	ErrorMessageType        = "error"
+)
+
+// clientMessage is a frame sent by the client, e.g.
+// {"action": "subscribe", "topic": "posts:1"}.
+type clientMessage struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+type Client struct {
+	hub       *Hub
+	socket    *websocket.Conn
+	send      chan []byte
+	claims    *models.AppClaims
+	userId    int64
+	expiresAt time.Time
+	topics    map[string]bool
+	// revoked asks the client to check whether its token was revoked.
+	revoked chan struct{}
+}
+
+func NewClient(hub *Hub, socket *websocket.Conn, claims *models.AppClaims) *Client {
+	client := &Client{
+		hub:     hub,
+		socket:  socket,
+		send:    make(chan []byte, 256),
+		claims:  claims,
+		userId:  claims.UserId,
+		topics:  make(map[string]bool),
+		revoked: make(chan struct{}, 1),
+	}
+
+	if claims.ExpiresAt != 0 {
+		client.expiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	return client
+}
+
+func (c *Client) UserId() int64 {
+	return c.userId
+}
+
+// readPump handles subscribe/unsubscribe frames sent by the client and
+// unregisters it once the connection goes away.
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister <- c
+		c.socket.Close()
+	}()
+
+	c.socket.SetReadLimit(maxMessageSize)
+	c.socket.SetReadDeadline(time.Now().Add(pongWait))
+	c.socket.SetPongHandler(func(string) error {
+		return c.socket.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.socket.ReadMessage()
+
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Println("websocket read error: ", err)
+			}
+			return
+		}
+
+		var message clientMessage
+
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.hub.reply(c, ErrorMessageType, "invalid message")
+			continue
+		}
+
+		switch message.Action {
+		case SubscribeAction, UnsubscribeAction:
+			if !models.IsValidTopic(message.Topic) {
+				c.hub.reply(c, ErrorMessageType, "invalid topic")
+				continue
+			}
+
+			c.hub.subscriptions <- &subscription{
+				client:    c,
+				topic:     message.Topic,
+				subscribe: message.Action == SubscribeAction,
+			}
+		default:
+			c.hub.reply(c, ErrorMessageType, "unknown action")
+		}
+	}
+}
+
+// writePump is the only goroutine allowed to write to the socket. It also
+// closes the connection once the token it was opened with expires or is
+// revoked.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	var expired <-chan time.Time
+
+	if !c.expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(c.expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	defer func() {
+		ticker.Stop()
+		c.socket.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.socket.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if !ok {
+				c.socket.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if err := c.socket.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.socket.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := c.socket.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-expired:
+			c.socket.SetWriteDeadline(time.Now().Add(writeWait))
+			c.socket.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"))
+			return
+		case <-c.revoked:
+			revoked, err := revocation.IsRevoked(context.Background(), c.claims)
+
+			if err != nil {
+				log.Println("Error checking token revocation: ", err)
+				continue
+			}
+
+			if revoked {
+				c.socket.SetWriteDeadline(time.Now().Add(writeWait))
+				c.socket.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token revoked"))
+				return
+			}
+		}
+	}
+}
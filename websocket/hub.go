@@ -0,0 +1,333 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"tincho.dev/rest-ws/models"
+)
+
+// Subprotocol lets browsers, which can't set an Authorization header on the
+// handshake, send the token as "Sec-WebSocket-Protocol: bearer, <token>".
+const Subprotocol = "bearer"
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin:  func(r *http.Request) bool { return true },
+	Subprotocols: []string{Subprotocol},
+}
+
+type directMessage struct {
+	userId int64
+	data   []byte
+}
+
+type publication struct {
+	topics []string
+	data   []byte
+}
+
+type subscription struct {
+	client    *Client
+	topic     string
+	subscribe bool
+}
+
+type reply struct {
+	client *Client
+	data   []byte
+}
+
+type Hub struct {
+	clients       map[*Client]bool
+	topics        map[string]map[*Client]bool
+	register      chan *Client
+	unregister    chan *Client
+	publish       chan *publication
+	direct        chan *directMessage
+	subscriptions chan *subscription
+	replies       chan *reply
+	revoked       chan int64
+
+	// online counts the live connections of each user. It is only written by
+	// the hub goroutine, but read from request handlers.
+	onlineMutex sync.RWMutex
+	online      map[int64]int
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients:       make(map[*Client]bool),
+		topics:        make(map[string]map[*Client]bool),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		publish:       make(chan *publication, 256),
+		direct:        make(chan *directMessage, 256),
+		subscriptions: make(chan *subscription),
+		replies:       make(chan *reply, 256),
+		revoked:       make(chan int64, 256),
+		online:        make(map[int64]int),
+	}
+}
+
+// Run owns the clients and topics maps; every change to them goes through
+// the hub channels.
+func (h *Hub) Run() {
+	for {
+		select {
+		case client := <-h.register:
+			h.clients[client] = true
+			h.connected(client.userId)
+			log.Println("Client connected, total: ", len(h.clients))
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				h.remove(client)
+				log.Println("Client disconnected, total: ", len(h.clients))
+			}
+		case message := <-h.publish:
+			h.deliver(message)
+		case userId := <-h.revoked:
+			for client := range h.clients {
+				if client.userId != userId {
+					continue
+				}
+
+				select {
+				case client.revoked <- struct{}{}:
+				default:
+					// A check is already pending.
+				}
+			}
+		case message := <-h.direct:
+			for client := range h.clients {
+				if client.userId == message.userId {
+					h.send(client, message.data)
+				}
+			}
+		case sub := <-h.subscriptions:
+			if _, ok := h.clients[sub.client]; !ok {
+				continue
+			}
+
+			if sub.subscribe {
+				h.subscribe(sub.client, sub.topic)
+				h.send(sub.client, encode(SubscribedMessageType, sub.topic))
+			} else {
+				h.unsubscribe(sub.client, sub.topic)
+				h.send(sub.client, encode(UnsubscribedMessageType, sub.topic))
+			}
+		case r := <-h.replies:
+			if _, ok := h.clients[r.client]; ok {
+				h.send(r.client, r.data)
+			}
+		}
+	}
+}
+
+func (h *Hub) deliver(message *publication) {
+	recipients := make(map[*Client]bool)
+
+	for _, topic := range message.topics {
+		for client := range h.topics[topic] {
+			recipients[client] = true
+		}
+	}
+
+	for client := range recipients {
+		h.send(client, message.data)
+	}
+}
+
+// connected and disconnected keep the per-user connection count, announcing a
+// user only on their first connection and their last disconnection.
+func (h *Hub) connected(userId int64) {
+	h.onlineMutex.Lock()
+	h.online[userId]++
+	first := h.online[userId] == 1
+	h.onlineMutex.Unlock()
+
+	if first {
+		h.announce(models.PresenceOnlineEvent, userId)
+	}
+}
+
+func (h *Hub) disconnected(userId int64) {
+	h.onlineMutex.Lock()
+	h.online[userId]--
+	last := h.online[userId] <= 0
+
+	if last {
+		delete(h.online, userId)
+	}
+
+	h.onlineMutex.Unlock()
+
+	if last {
+		h.announce(models.PresenceOfflineEvent, userId)
+	}
+}
+
+func (h *Hub) announce(eventType string, userId int64) {
+	h.deliver(&publication{
+		topics: []string{models.PresenceTopic},
+		data:   encode(eventType, models.PresencePayload{UserId: userId}),
+	})
+}
+
+func (h *Hub) subscribe(client *Client, topic string) {
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Client]bool)
+	}
+
+	h.topics[topic][client] = true
+	client.topics[topic] = true
+}
+
+func (h *Hub) unsubscribe(client *Client, topic string) {
+	delete(h.topics[topic], client)
+	delete(client.topics, topic)
+
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+func (h *Hub) remove(client *Client) {
+	for topic := range client.topics {
+		h.unsubscribe(client, topic)
+	}
+
+	delete(h.clients, client)
+	close(client.send)
+	h.disconnected(client.userId)
+}
+
+// send skips clients that are gone: dropping a client announces it offline,
+// which can drop others while a caller still ranges over its recipients.
+func (h *Hub) send(client *Client, message []byte) {
+	if !h.clients[client] {
+		return
+	}
+
+	select {
+	case client.send <- message:
+	default:
+		// The client can't keep up, drop it instead of blocking the hub.
+		h.remove(client)
+	}
+}
+
+func (h *Hub) reply(client *Client, messageType string, payload any) {
+	h.replies <- &reply{client: client, data: encode(messageType, payload)}
+}
+
+func encode(messageType string, payload any) []byte {
+	data, _ := json.Marshal(models.Event{Type: messageType, Payload: payload})
+
+	return data
+}
+
+// IsOnline reports whether the user has at least one live connection.
+func (h *Hub) IsOnline(userId int64) bool {
+	h.onlineMutex.RLock()
+	defer h.onlineMutex.RUnlock()
+
+	return h.online[userId] > 0
+}
+
+// OnlineUsers returns the ids of every user with at least one live connection.
+func (h *Hub) OnlineUsers() []int64 {
+	h.onlineMutex.RLock()
+	defer h.onlineMutex.RUnlock()
+
+	users := make([]int64, 0, len(h.online))
+
+	for userId := range h.online {
+		users = append(users, userId)
+	}
+
+	return users
+}
+
+// Publish delivers a message once to every client subscribed to at least one
+// of the given topics.
+func (h *Hub) Publish(message any, topics ...string) {
+	data, err := json.Marshal(message)
+
+	if err != nil {
+		log.Println("Error marshalling published message: ", err)
+		return
+	}
+
+	h.publish <- &publication{topics: topics, data: data}
+}
+
+// SendToUser delivers a message to every connection opened by the given user.
+func (h *Hub) SendToUser(userId int64, message any) {
+	data, err := json.Marshal(message)
+
+	if err != nil {
+		log.Println("Error marshalling direct message: ", err)
+		return
+	}
+
+	h.direct <- &directMessage{userId: userId, data: data}
+}
+
+// CloseRevoked closes the connections of the user opened with a token that
+// has been revoked since.
+func (h *Hub) CloseRevoked(userId int64) {
+	h.revoked <- userId
+}
+
+// Send delivers a message to a single connection, if it is still open.
+func (h *Hub) Send(client *Client, message any) {
+	data, err := json.Marshal(message)
+
+	if err != nil {
+		log.Println("Error marshalling message: ", err)
+		return
+	}
+
+	h.replies <- &reply{client: client, data: data}
+}
+
+// HandleWebSocket upgrades an already authenticated request and binds the
+// resulting connection to the claims' user. It returns nil when the upgrade
+// fails.
+func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request, claims *models.AppClaims) *Client {
+	socket, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		log.Println("Error upgrading connection: ", err)
+		return nil
+	}
+
+	client := NewClient(h, socket, claims)
+	h.register <- client
+
+	go client.writePump()
+	go client.readPump()
+
+	return client
+}
+
+// TokenFromRequest reads the token from the "token" query parameter or from
+// the value following the bearer subprotocol.
+func TokenFromRequest(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+
+	protocols := websocket.Subprotocols(r)
+
+	for i, protocol := range protocols {
+		if protocol == Subprotocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	return ""
+}